@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,33 +18,107 @@ const helpText = `Trace - Git-like environment versioning
 Usage: trace <command> [options]
 
 Commands:
-  init                Initialize trace repository in current directory
+  init [--encrypt]    Initialize trace repository in current directory
   track <file>...     Add files to tracking list
   snap <message>      Create a snapshot with the given message
-  log [-n <count>]    Show commit history
+  log [rev] [-n <n>]  Show commit history (rev may be a range A..B)
   status              Show current environment drift from HEAD
+  verify [rev]        Check the environment against a commit (for CI)
+  env template [rev]  Write .env.example from the .env files of a commit
+  env check [file]    List keys of .env.example not set locally
   kill <port|pid>     Kill a process by Port or PID
-  watch               Monitor for changes in real-time
-  diff [commit]       Compare working environment with a commit
+  ports [--kill]      Show who holds each project port; offer to stop
+                      processes outside the project that block one
+  up [name]...        Start the services declared in the config
+  down [name]...      Stop running services
+  restart <name>      Restart a service
+  watch               Monitor for changes in real-time, with tabs for the
+                      diff of changed files, history and process details
+  daemon [-i <d>]     Serve status, diff, log and processes as JSON on
+                      .trace/daemon.sock, streaming changes at /events
+  diff [a] [b]        Compare working environment with a commit, or two commits
   restore [options]   Restore tracked files to a previous state
   checkout <ref>      Switch to a branch or commit
   branch [name]       List, create, or delete branches
+  reflog [branch]     Show the history of HEAD or branch movements
+  gc [--dry-run]      Remove unreachable commits and blobs
+     [--expire=<age>] Drop reflog entries older than <age> first (default:
+                      "90 days ago"; "now" or "never" work too)
+  fsck                Verify integrity of commits, blobs and refs
+  repack              Pack all objects into a single compressed packfile
+  rekey               Re-encrypt stored file contents with a new key
+
+Global Options:
+  --format <fmt>      Output format for status, diff, log and branch:
+                      text (default), json or ndjson
+
+Diff Options:
+  <a> <b>, <a>..<b>   Compare two commits instead of the working environment
+  --stat              Show a summary of changed files and keys
+  --name-only         Show only the names of changed files and keys
+  --env-only          Only compare env keys
+  --files-only        Only compare files
+  -p, --patch         Show content changes of modified files (key paths
+                      for JSON/YAML/TOML/INI, line diffs otherwise)
+  -U<n>               Show <n> lines of context in patches (default: 3)
+  --text              Use line diffs for JSON/YAML/TOML/INI files too
+
+Verify Options:
+  --ignore-key <key>  Ignore an env key (glob patterns work, repeatable)
+  --ignore-file <f>   Ignore a tracked file (glob patterns work, repeatable)
+  --require-process <name>
+                      Fail if no project process has this name (repeatable)
+  Exit codes: 0 clean, 1 error, 2 drift, 3 missing required keys,
+              4 missing required processes, 5 policy violations,
+              6 failed health checks
+
+Watch Options:
+  -i, --interval <d>  Refresh processes, ports and health checks every <d>
+                      (default: 5s); tracked files are watched for changes
+  --auto-snap         Snapshot to auto/<branch> whenever tracked files settle
+                      after a change (HEAD and the branch do not move)
+  --headless          Print one JSON event per change instead of the TUI:
+                      files modified, env keys added or removed, processes
+                      started or stopped, ports opened or closed
+  --until-clean       Exit 0 once nothing drifts from HEAD, expected ports
+                      listen and health checks pass (with --headless)
+  --timeout <d>       Exit after <d>; with --until-clean, exit 2 if the
+                      environment is not clean by then
 
 Restore Options:
-  --commit <hash>     Restore from specific commit (default: HEAD)
+  --commit <rev>      Restore from specific commit (default: HEAD); a range
+                      A..B restores only files changed between A and B
   --no-backup         Don't create backup files before restoring
   <file>...           Restore only specific files
 
+Revisions:
+  <hash>, <branch>, HEAD    A commit, branch tip or HEAD (prefixes of 4+ chars work)
+  <rev>~N, <rev>^           The N-th parent / the parent
+  <ref>@{n}                 The n-th previous position of HEAD or a branch
+  auto/<branch>             The latest auto-snapshot taken on a branch by watch
+  <rev>@{yesterday}         The last commit at or before a date
+                            (also "2 days ago", 2026-10-01, "2026-10-01 14:00")
+  A..B                      Commits reachable from B but not from A
+
 Examples:
   trace init
   trace snap "initial environment setup"
   trace log -n 5
   trace status
   trace diff HEAD~1
+  trace verify main --ignore-key LOG_LEVEL
+  trace env template -o .env.example
+  trace up && trace restart web
+  trace watch --auto-snap
+  trace log auto/main
+  trace watch --headless --until-clean --timeout 2m -i 1s
+  trace diff main@{yesterday}..main
+  trace log HEAD~3..HEAD
   trace restore
   trace restore --commit abc123 .env
   trace branch staging
   trace checkout main
+  trace checkout HEAD@{2}
 
 Version: %s
 `
@@ -53,14 +129,42 @@ func main() {
 		return
 	}
 
-	command := os.Args[1]
-	args := os.Args[2:]
-
+	// Global flags may appear anywhere on the command line
+	var rest []string
 	var err error
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case arg == "--format" && i+1 < len(os.Args):
+			err = cli.SetOutputFormat(os.Args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--format="):
+			err = cli.SetOutputFormat(strings.TrimPrefix(arg, "--format="))
+		default:
+			rest = append(rest, arg)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(rest) == 0 {
+		fmt.Printf(helpText, version)
+		return
+	}
+
+	command := rest[0]
+	args := rest[1:]
 
 	switch command {
 	case "init":
-		err = cli.Init()
+		encrypt := false
+		for _, arg := range args {
+			if arg == "--encrypt" {
+				encrypt = true
+			}
+		}
+		err = cli.Init(encrypt)
 
 	case "snap":
 		message := strings.Join(args, " ")
@@ -68,27 +172,62 @@ func main() {
 
 	case "log":
 		count := 0
-		for i, arg := range args {
-			if arg == "-n" && i+1 < len(args) {
+		rev := ""
+		for i := 0; i < len(args); i++ {
+			if args[i] == "-n" && i+1 < len(args) {
 				fmt.Sscanf(args[i+1], "%d", &count)
-				break
+				i++
+			} else if rev == "" {
+				rev = args[i]
 			}
 		}
-		err = cli.Log(count)
+		err = cli.Log(rev, count)
 
 	case "watch":
-		interval := 2 * time.Second
+		opts := cli.WatchOptions{Interval: 5 * time.Second}
+		for i, arg := range args {
+			if (arg == "-i" || arg == "--interval") && i+1 < len(args) {
+				if d, parseErr := time.ParseDuration(args[i+1]); parseErr == nil {
+					opts.Interval = d
+				} else {
+					fmt.Printf("Invalid interval %s, using default 5s\n", args[i+1])
+				}
+			}
+			if arg == "--timeout" && i+1 < len(args) {
+				if d, parseErr := time.ParseDuration(args[i+1]); parseErr == nil {
+					opts.Timeout = d
+				} else {
+					err = fmt.Errorf("invalid timeout %s", args[i+1])
+				}
+			}
+			switch arg {
+			case "--auto-snap":
+				opts.AutoSnap = true
+			case "--headless":
+				opts.Headless = true
+			case "--until-clean":
+				opts.UntilClean = true
+			}
+		}
+		if err == nil && (opts.UntilClean || opts.Timeout > 0) && !opts.Headless {
+			err = fmt.Errorf("--until-clean and --timeout require --headless")
+		}
+		if err == nil {
+			err = cli.Watch(opts)
+		}
+
+	case "daemon":
+		interval := 5 * time.Second
 		for i, arg := range args {
 			if (arg == "-i" || arg == "--interval") && i+1 < len(args) {
 				if d, parseErr := time.ParseDuration(args[i+1]); parseErr == nil {
 					interval = d
 				} else {
-					fmt.Printf("Invalid interval %s, using default 2s\n", args[i+1])
+					fmt.Printf("Invalid interval %s, using default 5s\n", args[i+1])
 				}
-				break
 			}
 		}
-		err = cli.Watch(interval)
+		err = cli.Daemon(interval)
 
 	case "track":
 		err = cli.Track(args)
@@ -96,6 +235,75 @@ func main() {
 	case "status":
 		err = cli.Status()
 
+	case "verify":
+		opts := cli.VerifyOptions{}
+		for i := 0; i < len(args); i++ {
+			switch {
+			case args[i] == "--ignore-key" && i+1 < len(args):
+				opts.IgnoreKeys = append(opts.IgnoreKeys, args[i+1])
+				i++
+			case args[i] == "--ignore-file" && i+1 < len(args):
+				opts.IgnoreFiles = append(opts.IgnoreFiles, args[i+1])
+				i++
+			case args[i] == "--require-process" && i+1 < len(args):
+				opts.RequireProcesses = append(opts.RequireProcesses, args[i+1])
+				i++
+			case opts.Ref == "":
+				opts.Ref = args[i]
+			}
+		}
+		err = cli.Verify(opts)
+
+	case "env":
+		sub := ""
+		if len(args) > 0 {
+			sub = args[0]
+			args = args[1:]
+		}
+		switch sub {
+		case "template":
+			rev, output := "", ""
+			for i := 0; i < len(args); i++ {
+				if (args[i] == "-o" || args[i] == "--output") && i+1 < len(args) {
+					output = args[i+1]
+					i++
+				} else if rev == "" {
+					rev = args[i]
+				}
+			}
+			err = cli.EnvTemplate(rev, output)
+		case "check":
+			template := ""
+			if len(args) > 0 {
+				template = args[0]
+			}
+			err = cli.EnvCheck(template)
+		default:
+			err = fmt.Errorf("usage: trace env <template [rev] [-o file]|check [file]>")
+		}
+
+	case "ports":
+		kill := false
+		for _, arg := range args {
+			if arg == "--kill" {
+				kill = true
+			}
+		}
+		err = cli.Ports(kill)
+
+	case "up":
+		err = cli.Up(args)
+
+	case "down":
+		err = cli.Down(args)
+
+	case "restart":
+		if len(args) != 1 {
+			err = fmt.Errorf("usage: trace restart <name>")
+		} else {
+			err = cli.Restart(args[0])
+		}
+
 	case "kill":
 		if len(args) < 1 {
 			err = fmt.Errorf("usage: trace kill <port|pid>")
@@ -104,11 +312,46 @@ func main() {
 		}
 
 	case "diff":
-		target := ""
-		if len(args) > 0 {
-			target = args[0]
+		opts := cli.DiffOptions{Context: -1}
+		for _, arg := range args {
+			switch arg {
+			case "--stat":
+				opts.Stat = true
+			case "--name-only":
+				opts.NameOnly = true
+			case "--env-only":
+				opts.EnvOnly = true
+			case "--files-only":
+				opts.FilesOnly = true
+			case "-p", "--patch":
+				opts.Patch = true
+			case "--text":
+				opts.Patch = true
+				opts.Text = true
+			default:
+				if strings.HasPrefix(arg, "-U") || strings.HasPrefix(arg, "--unified=") {
+					opts.Patch = true
+					value := strings.TrimPrefix(strings.TrimPrefix(arg, "-U"), "--unified=")
+					if n, convErr := strconv.Atoi(value); convErr == nil && n >= 0 {
+						opts.Context = n
+					} else {
+						err = fmt.Errorf("invalid context %q: usage: trace diff -U<lines>", value)
+					}
+					continue
+				}
+				switch {
+				case opts.From == "":
+					opts.From = arg
+				case opts.To == "":
+					opts.To = arg
+				default:
+					err = fmt.Errorf("usage: trace diff [<rev> [<rev>] | <rev>..<rev>]")
+				}
+			}
+		}
+		if err == nil {
+			err = cli.Diff(opts)
 		}
-		err = cli.Diff(target)
 
 	case "restore":
 		opts := cli.RestoreOptions{}
@@ -148,6 +391,37 @@ func main() {
 		}
 		err = cli.Branch(name, delete)
 
+	case "reflog":
+		ref := ""
+		if len(args) > 0 {
+			ref = args[0]
+		}
+		err = cli.Reflog(ref)
+
+	case "gc":
+		dryRun, expire := false, ""
+		for i := 0; i < len(args); i++ {
+			switch {
+			case args[i] == "--dry-run" || args[i] == "-n":
+				dryRun = true
+			case args[i] == "--expire" && i+1 < len(args):
+				expire = args[i+1]
+				i++
+			case strings.HasPrefix(args[i], "--expire="):
+				expire = strings.TrimPrefix(args[i], "--expire=")
+			}
+		}
+		err = cli.GC(dryRun, expire)
+
+	case "fsck":
+		err = cli.Fsck()
+
+	case "repack":
+		err = cli.Repack()
+
+	case "rekey":
+		err = cli.Rekey()
+
 	case "help", "--help", "-h":
 		fmt.Printf(helpText, version)
 		return
@@ -164,6 +438,10 @@ func main() {
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var exitErr *cli.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
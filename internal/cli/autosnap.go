@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/diff"
+	"trace/internal/store"
+)
+
+// DefaultAutoSnapSettle is how long tracked files must stay unchanged before
+// watch takes an auto-snapshot.
+const DefaultAutoSnapSettle = 3 * time.Second
+
+// AutoSnap records the working environment on the auto-snapshot ref of the
+// current branch (auto/<branch>) if it differs from the last auto-snapshot,
+// or from HEAD. HEAD and the branch do not move. It returns nil if there was
+// nothing to record.
+func AutoSnap() (*core.Commit, error) {
+	branch, err := core.GetCurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("get branch: %w", err)
+	}
+	head, err := core.GetHEAD()
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD: %w", err)
+	}
+	parent, err := autoParent(branch, head)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := collectSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("collect snapshot: %w", err)
+	}
+
+	var base core.Snapshot
+	if parent != "" {
+		parentCommit, err := store.LoadCommit(parent)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", core.ShortHash(parent), err)
+		}
+		base = parentCommit.Snapshot
+	}
+	envDiff, fileDiff := diff.CompareSnapshots(&base, &snapshot)
+	if envDiff.IsEmpty() && fileDiff.IsEmpty() {
+		return nil, nil
+	}
+
+	message := "auto: " + diff.Summary(envDiff, fileDiff)
+	commit := core.NewCommit(parent, message, snapshot)
+	if err := store.SaveCommit(commit); err != nil {
+		return nil, fmt.Errorf("save commit: %w", err)
+	}
+	if err := core.SetAuto(branch, commit.Hash, message); err != nil {
+		return nil, fmt.Errorf("update %s: %w", core.AutoName(branch), err)
+	}
+	return commit, nil
+}
+
+// autoParent continues the auto-snapshots of a branch while they build on
+// its HEAD, and starts over from HEAD once the branch has moved on.
+func autoParent(branch, head string) (string, error) {
+	tip, err := core.GetAuto(branch)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", core.AutoName(branch), err)
+	}
+	if tip == "" {
+		return head, nil
+	}
+	if head == "" {
+		return tip, nil
+	}
+
+	history, err := store.GetCommitHistory(tip)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", core.AutoName(branch), err)
+	}
+	for _, c := range history {
+		if c.Hash == head {
+			return tip, nil
+		}
+	}
+	return head, nil
+}
+
+// autoSnapSettle returns the configured settle time of auto-snapshots.
+func autoSnapSettle(cfg config.Config) time.Duration {
+	if d, err := time.ParseDuration(cfg.AutoSnap.Settle); err == nil && d > 0 {
+		return d
+	}
+	return DefaultAutoSnapSettle
+}
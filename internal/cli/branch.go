@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"os"
 
 	"trace/internal/core"
 )
@@ -29,6 +28,15 @@ func listBranches() error {
 
 	currentBranch, _ := core.GetCurrentBranch()
 
+	if machineOutput() {
+		var list []branchJSON
+		for _, branch := range branches {
+			hash, _ := core.GetBranch(branch)
+			list = append(list, branchJSON{Name: branch, Hash: hash, Current: branch == currentBranch})
+		}
+		return emitList(list)
+	}
+
 	if len(branches) == 0 {
 		fmt.Println("No branches yet.")
 		return nil
@@ -61,7 +69,7 @@ func createBranch(name string) error {
 
 	if head == "" {
 		// No commits yet, just create empty branch and switch to it
-		if err := core.SetHEADToBranch(name); err != nil {
+		if err := core.SetHEADToBranch(name, "branch", "created empty branch "+name); err != nil {
 			return fmt.Errorf("create branch: %w", err)
 		}
 		fmt.Printf("Created and switched to new branch '%s'\n", name)
@@ -69,12 +77,12 @@ func createBranch(name string) error {
 	}
 
 	// Create branch pointing to HEAD
-	if err := core.SetBranch(name, head); err != nil {
+	if err := core.SetBranch(name, head, "branch", "created from HEAD"); err != nil {
 		return fmt.Errorf("create branch: %w", err)
 	}
 
 	// Switch to new branch
-	if err := core.SetHEADToBranch(name); err != nil {
+	if err := core.SetHEADToBranch(name, "checkout", "moving to "+name); err != nil {
 		return fmt.Errorf("switch branch: %w", err)
 	}
 
@@ -95,12 +103,12 @@ func deleteBranch(name string) error {
 		return fmt.Errorf("branch '%s' not found", name)
 	}
 
-	// Delete branch file
-	branchPath := core.HeadsDir + "/" + name
-	if err := os.Remove(branchPath); err != nil {
+	// Delete branch file (its reflog keeps the commits until its entries expire in gc)
+	if err := core.DeleteBranch(name); err != nil {
 		return fmt.Errorf("delete branch: %w", err)
 	}
 
-	fmt.Printf("Deleted branch '%s'\n", name)
+	fmt.Printf("Deleted branch '%s' (was %s)\n", name, core.ShortHash(hash))
+	fmt.Printf("   To recover it: trace reflog %s ('trace gc' keeps its commits until the reflog expires)\n", name)
 	return nil
 }
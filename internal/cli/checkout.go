@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"os"
 
 	"trace/internal/core"
 	"trace/internal/store"
@@ -10,52 +9,69 @@ import (
 
 // Checkout moves HEAD to a specific commit (detached HEAD state).
 func Checkout(ref string) error {
+	branch, commit, err := checkoutRef(ref)
+	if err != nil {
+		return err
+	}
+
+	if branch != "" {
+		if commit != nil {
+			fmt.Printf("Switched to branch '%s'\n", branch)
+			fmt.Printf("   Latest: %s - %s\n", commit.ShortHash(), commit.Message)
+		} else {
+			fmt.Printf("Switched to branch '%s' (no commits)\n", branch)
+		}
+		return nil
+	}
+
+	fmt.Printf("HEAD is now at %s %s\n", commit.ShortHash(), commit.Message)
+	fmt.Println("\n⚠️  You are in 'detached HEAD' state.")
+	fmt.Println("   To return to a branch: trace checkout main")
+
+	return nil
+}
+
+// checkoutRef moves HEAD to a branch, or detaches it at a commit, without
+// printing. It returns the branch (empty when detached) and the commit HEAD
+// points to, which is nil for a branch without commits.
+func checkoutRef(ref string) (string, *core.Commit, error) {
 	// Try to resolve as branch first
 	branches, err := core.ListBranches()
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
 	for _, branch := range branches {
 		if branch == ref {
 			// Checkout branch
-			if err := core.SetHEADToBranch(branch); err != nil {
-				return fmt.Errorf("set HEAD: %w", err)
+			if err := core.SetHEADToBranch(branch, "checkout", "moving to "+branch); err != nil {
+				return "", nil, fmt.Errorf("set HEAD: %w", err)
 			}
 
 			hash, _ := core.GetBranch(branch)
 			if hash != "" {
-				commit, err := store.LoadCommit(hash)
-				if err == nil {
-					fmt.Printf("Switched to branch '%s'\n", branch)
-					fmt.Printf("   Latest: %s - %s\n", commit.ShortHash(), commit.Message)
+				if commit, err := store.LoadCommit(hash); err == nil {
+					return branch, commit, nil
 				}
-			} else {
-				fmt.Printf("Switched to branch '%s' (no commits)\n", branch)
 			}
-			return nil
+			return branch, nil, nil
 		}
 	}
 
 	// Resolve as commit hash
 	hash, err := store.ResolveCommit(ref)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 
 	commit, err := store.LoadCommit(hash)
 	if err != nil {
-		return fmt.Errorf("load commit: %w", err)
+		return "", nil, fmt.Errorf("load commit: %w", err)
 	}
 
 	// Set HEAD directly to commit (detached)
-	if err := os.WriteFile(core.HeadFile, []byte(hash+"\n"), 0644); err != nil {
-		return fmt.Errorf("set HEAD: %w", err)
+	if err := core.DetachHEAD(hash, "checkout", "moving to "+ref); err != nil {
+		return "", nil, fmt.Errorf("set HEAD: %w", err)
 	}
-
-	fmt.Printf("HEAD is now at %s %s\n", commit.ShortHash(), commit.Message)
-	fmt.Println("\n⚠️  You are in 'detached HEAD' state.")
-	fmt.Println("   To return to a branch: trace checkout main")
-
-	return nil
+	return "", commit, nil
 }
@@ -0,0 +1,364 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/daemon"
+	"trace/internal/diff"
+	"trace/internal/monitor"
+	"trace/internal/policy"
+	"trace/internal/store"
+	"trace/internal/watcher"
+)
+
+// Daemon keeps drift, processes and ports of the project in memory and
+// serves them as JSON on .trace/daemon.sock until interrupted. Drift is
+// refreshed when tracked files or refs change, processes every interval;
+// each change is streamed to /events subscribers.
+func Daemon(interval time.Duration) error {
+	root, err := core.FindProjectRoot()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(root, core.TraceDir)); err != nil {
+		return fmt.Errorf("not a trace repository (run \"trace init\")")
+	}
+
+	path := filepath.Join(root, core.TraceDir, daemon.SocketName)
+	l, err := daemon.Listen(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	svc := &daemonService{
+		driftRequests: make(chan struct{}, 1),
+		procsRequests: make(chan struct{}, 1),
+	}
+	svc.server = daemon.New(svc)
+	svc.refreshDrift()
+	svc.refreshProcesses()
+
+	w, watchErr := startWatcher()
+	var changes <-chan watcher.Change
+	if watchErr == nil {
+		defer w.Close()
+		changes = w.Changes
+	}
+
+	hs := &http.Server{Handler: svc.server.Handler()}
+	go hs.Serve(l)
+	// Close rather than Shutdown: event streams never finish by themselves
+	defer hs.Close()
+
+	fmt.Printf("👂 Serving %s on %s\n", root, path)
+	fmt.Printf("  (try \"curl --unix-socket %s http://trace/status\")\n", path)
+	if watchErr != nil {
+		fmt.Printf("  File notifications unavailable (%v); polling every %s\n", watchErr, interval)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("\n👋 Daemon stopped.")
+			return nil
+
+		case <-ticker.C:
+			if changes == nil {
+				svc.refreshDrift()
+			}
+			svc.refreshProcesses()
+
+		case change, ok := <-changes:
+			if !ok {
+				changes = nil
+				continue
+			}
+			if change.Config() {
+				if files, err := watchedFiles(root); err == nil {
+					w.SetFiles(files)
+				}
+			}
+			svc.refreshDrift()
+			if change.Refs() || change.Config() {
+				svc.refreshProcesses()
+			}
+
+		case <-svc.driftRequests:
+			svc.refreshDrift()
+
+		case <-svc.procsRequests:
+			svc.refreshProcesses()
+		}
+	}
+}
+
+// daemonService is the cached model of the project behind the daemon API.
+type daemonService struct {
+	server *daemon.Server
+
+	mu    sync.Mutex
+	drift daemonDrift
+	procs daemonProcs
+
+	// Refreshes asked for by API handlers. Only the main loop refreshes, so
+	// that a slower, older refresh never overwrites a newer result.
+	driftRequests chan struct{}
+	procsRequests chan struct{}
+}
+
+// request asks the main loop for a refresh; requests made while one is
+// pending are merged.
+func request(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// daemonDrift is the part of the status that depends on files and refs.
+type daemonDrift struct {
+	Head     string
+	Env      diff.EnvDiff
+	Files    diff.FileDiff
+	Policy   []policy.Violation
+	Warnings []string
+	Err      error `json:"-"`
+}
+
+// daemonProcs is the part of the status that depends on running processes.
+type daemonProcs struct {
+	Processes []monitor.ProcessInfo
+	LiveEnv   diff.EnvDiff
+	Ports     PortStatus
+	Health    []monitor.HealthResult
+	Err       error `json:"-"`
+}
+
+// killJSON is the response of POST /kill.
+type killJSON struct {
+	PID    int32  `json:"pid"`
+	Reason string `json:"reason"`
+}
+
+func (s *daemonService) refreshDrift() {
+	var d daemonDrift
+	d.Head, d.Err = core.GetHEAD()
+	if d.Err == nil {
+		d.Env, d.Files, d.Err = getDrift()
+		if d.Err != nil && d.Err.Error() == "no commits" {
+			d.Err = nil
+		}
+	}
+	if d.Err == nil {
+		d.Policy, d.Err = checkPolicy()
+	}
+	if d.Err == nil {
+		d.Warnings, d.Err = envWarnings()
+	}
+
+	s.mu.Lock()
+	changed := !sameJSON(s.drift, d) || (s.drift.Err == nil) != (d.Err == nil)
+	s.drift = d
+	s.mu.Unlock()
+
+	if changed {
+		s.publish("drift")
+	}
+}
+
+func (s *daemonService) refreshProcesses() {
+	var p daemonProcs
+	cwd, _ := os.Getwd()
+	// Processes that cannot be listed are left out, as in status
+	p.Processes, _ = monitor.GetProjectProcesses(cwd)
+	p.LiveEnv, p.Err = liveEnvDrift(p.Processes)
+	if p.Err == nil {
+		p.Ports, p.Err = checkPortStatus(p.Processes)
+	}
+	if p.Err == nil {
+		p.Health, p.Err = checkHealth()
+	}
+
+	s.mu.Lock()
+	changed := !sameJSON(s.procs.comparable(), p.comparable()) || (s.procs.Err == nil) != (p.Err == nil)
+	s.procs = p
+	s.mu.Unlock()
+
+	if changed {
+		s.publish("processes")
+	}
+}
+
+// comparable drops what changes on every refresh without meaning anything.
+func (p daemonProcs) comparable() daemonProcs {
+	health := make([]monitor.HealthResult, len(p.Health))
+	for i, h := range p.Health {
+		h.Duration = 0
+		health[i] = h
+	}
+	p.Health = health
+	return p
+}
+
+// publish sends the current status, or its error, as an event.
+func (s *daemonService) publish(kind string) {
+	status, err := s.Status()
+	if err != nil {
+		s.server.Publish(daemon.Event{Type: kind, Data: map[string]string{"error": err.Error()}})
+		return
+	}
+	s.server.Publish(daemon.Event{Type: kind, Data: status})
+}
+
+func (s *daemonService) Status() (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.drift.Err != nil {
+		return nil, s.drift.Err
+	}
+	if s.procs.Err != nil {
+		return nil, s.procs.Err
+	}
+	return newStatusJSON(withLiveEnv(s.drift.Env, s.procs.LiveEnv), s.drift.Files, s.procs.Processes, s.drift.Policy, s.drift.Warnings, s.procs.Ports, s.procs.Health), nil
+}
+
+func (s *daemonService) Diff(from, to string) (any, error) {
+	s.mu.Lock()
+	procs := s.procs.Processes
+	s.mu.Unlock()
+	return diffData(from, to, procs)
+}
+
+func (s *daemonService) Log(rev string, count int) (any, error) {
+	head, err := core.GetHEAD()
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD: %w", err)
+	}
+	if head == "" {
+		return []*core.Commit{}, nil
+	}
+	return commitLog(head, rev, count)
+}
+
+func (s *daemonService) Processes() (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	procs := make([]monitor.ProcessInfo, 0, len(s.procs.Processes))
+	for _, p := range s.procs.Processes {
+		if p.Ports == nil {
+			p.Ports = []int{}
+		}
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+func (s *daemonService) Kill(target string) (any, error) {
+	pid, reason, err := ResolveKillTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	if err := KillPID(pid); err != nil {
+		return nil, err
+	}
+
+	result := killJSON{PID: pid, Reason: reason}
+	s.server.Publish(daemon.Event{Type: "kill", Data: result})
+	request(s.procsRequests)
+	return result, nil
+}
+
+func (s *daemonService) Snap(message string) (any, error) {
+	commit, err := CreateSnapshot(message)
+	if err != nil {
+		return nil, err
+	}
+
+	s.server.Publish(daemon.Event{Type: "snap", Data: commit})
+	request(s.driftRequests)
+	return commit, nil
+}
+
+// diffData compares from (default HEAD, or a range A..B) with to, or with
+// the working environment if to is empty, in the schema of diff --format json.
+// The working environment is read without saving blobs, using procs, the
+// project processes from the last refresh.
+func diffData(from, to string, procs []monitor.ProcessInfo) (diffJSON, error) {
+	if store.IsRange(to) || store.IsRange(from) && to != "" {
+		return diffJSON{}, fmt.Errorf("a range cannot be combined with another revision")
+	}
+	if store.IsRange(from) {
+		var err error
+		if from, to, err = store.ResolveRange(from); err != nil {
+			return diffJSON{}, err
+		}
+	}
+	if from == "" {
+		head, err := core.GetHEAD()
+		if err != nil {
+			return diffJSON{}, fmt.Errorf("get HEAD: %w", err)
+		}
+		if head == "" {
+			return diffJSON{}, nil
+		}
+		from = head
+	}
+
+	fromHash, err := store.ResolveCommit(from)
+	if err != nil {
+		return diffJSON{}, err
+	}
+	fromCommit, err := store.LoadCommit(fromHash)
+	if err != nil {
+		return diffJSON{}, fmt.Errorf("load %s: %w", from, err)
+	}
+
+	result := diffJSON{From: fromHash}
+	var current core.Snapshot
+	if to == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return diffJSON{}, err
+		}
+		if current, err = captureSnapshot(cfg, procs, false); err != nil {
+			return diffJSON{}, fmt.Errorf("collect snapshot: %w", err)
+		}
+	} else {
+		if result.To, err = store.ResolveCommit(to); err != nil {
+			return diffJSON{}, err
+		}
+		toCommit, err := store.LoadCommit(result.To)
+		if err != nil {
+			return diffJSON{}, fmt.Errorf("load %s: %w", to, err)
+		}
+		current = toCommit.Snapshot
+	}
+
+	result.Env, result.Files = diff.CompareSnapshots(&fromCommit.Snapshot, &current)
+	result.Ports = diff.ComparePorts(&fromCommit.Snapshot, &current)
+	return result, nil
+}
+
+// sameJSON reports whether a and b encode to the same JSON.
+func sameJSON(a, b any) bool {
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && string(x) == string(y)
+}
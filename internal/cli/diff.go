@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -12,14 +13,54 @@ import (
 	"trace/internal/store"
 )
 
+// DiffOptions configures what is compared and how it is rendered.
+type DiffOptions struct {
+	From      string // Revision or range A..B to compare (empty = HEAD)
+	To        string // Second revision (empty = working environment)
+	Stat      bool   // Show a summary of changes
+	NameOnly  bool   // Show only the names of changed files and keys
+	EnvOnly   bool   // Only compare env keys
+	FilesOnly bool   // Only compare files
+	Patch     bool   // Show line-level content diffs of changed files
+	Context   int    // Context lines around changes in patches (negative = default)
+	Text      bool   // Use line diffs even for structured config files
+}
+
 // Diff compares the current state with a specific commit or between two commits.
-func Diff(target string) error {
+// A range (A..B) or a second revision compares the two commits without touching
+// the working state.
+func Diff(opts DiffOptions) error {
+	if opts.EnvOnly && opts.FilesOnly {
+		return fmt.Errorf("--env-only and --files-only are mutually exclusive")
+	}
+
+	if store.IsRange(opts.To) || store.IsRange(opts.From) && opts.To != "" {
+		return fmt.Errorf("usage: trace diff [<rev> [<rev>] | <rev>..<rev>]: a range cannot be combined with another revision")
+	}
+	if store.IsRange(opts.From) {
+		from, to, err := store.ResolveRange(opts.From)
+		if err != nil {
+			return err
+		}
+		return DiffCommits(from, to, opts)
+	}
+	if opts.To != "" {
+		from := opts.From
+		if from == "" {
+			from = core.HeadRef
+		}
+		return DiffCommits(from, opts.To, opts)
+	}
+
 	head, err := core.GetHEAD()
 	if err != nil {
 		return fmt.Errorf("get HEAD: %w", err)
 	}
 
 	if head == "" {
+		if machineOutput() {
+			return emit(diffJSON{})
+		}
 		fmt.Println("No commits yet.")
 		return nil
 	}
@@ -32,7 +73,7 @@ func Diff(target string) error {
 
 	var targetCommit *core.Commit
 
-	if target == "" {
+	if opts.From == "" {
 		// Compare with HEAD
 		targetCommit, err = store.LoadCommit(head)
 		if err != nil {
@@ -40,7 +81,7 @@ func Diff(target string) error {
 		}
 	} else {
 		// Resolve target reference
-		targetHash, err := store.ResolveCommit(target)
+		targetHash, err := store.ResolveCommit(opts.From)
 		if err != nil {
 			return err
 		}
@@ -51,44 +92,18 @@ func Diff(target string) error {
 		}
 	}
 
-	// Compare
-	envDiff, fileDiff := diff.CompareSnapshots(&targetCommit.Snapshot, &current)
-
-	if envDiff.IsEmpty() && fileDiff.IsEmpty() {
-		fmt.Println("✨ No differences found.")
-		return nil
-	}
-
-	// Render to string
-	var sb strings.Builder
-	if target == "" {
-		sb.WriteString(fmt.Sprintf("🔍 Comparing working environment with HEAD (%s)\n\n", targetCommit.ShortHash()))
+	var title string
+	if opts.From == "" {
+		title = fmt.Sprintf("🔍 Comparing working environment with HEAD (%s)", targetCommit.ShortHash())
 	} else {
-		sb.WriteString(fmt.Sprintf("🔍 Comparing working environment with %s (%s)\n\n", target, targetCommit.ShortHash()))
-	}
-
-	if !fileDiff.IsEmpty() {
-		diff.RenderFileDiff(&sb, fileDiff)
-	}
-
-	if !envDiff.IsEmpty() {
-		diff.RenderEnvDiff(&sb, envDiff)
+		title = fmt.Sprintf("🔍 Comparing working environment with %s (%s)", opts.From, targetCommit.ShortHash())
 	}
 
-	output := sb.String()
-
-	// interactive mode
-	if isatty.IsTerminal(os.Stdout.Fd()) {
-		return ShowDiffTUI(output)
-	}
-
-	// non-interactive
-	fmt.Print(output)
-	return nil
+	return showDiff(title, targetCommit.Hash, "", &targetCommit.Snapshot, &current, opts)
 }
 
 // DiffCommits compares two specific commits.
-func DiffCommits(from, to string) error {
+func DiffCommits(from, to string, opts DiffOptions) error {
 	fromHash, err := store.ResolveCommit(from)
 	if err != nil {
 		return fmt.Errorf("resolve %s: %w", from, err)
@@ -109,16 +124,51 @@ func DiffCommits(from, to string) error {
 		return fmt.Errorf("load %s: %w", to, err)
 	}
 
-	envDiff, fileDiff := diff.CompareSnapshots(&fromCommit.Snapshot, &toCommit.Snapshot)
+	title := fmt.Sprintf("🔍 Comparing %s..%s", fromCommit.ShortHash(), toCommit.ShortHash())
+	return showDiff(title, fromCommit.Hash, toCommit.Hash, &fromCommit.Snapshot, &toCommit.Snapshot, opts)
+}
+
+// showDiff compares two snapshots, applies the filters in opts and renders the
+// result, using the scrollable viewer for full diffs on a terminal.
+// fromHash and toHash identify the snapshots in JSON output (toHash is empty
+// for the working environment).
+func showDiff(title, fromHash, toHash string, old, new *core.Snapshot, opts DiffOptions) error {
+	envDiff, fileDiff := diff.CompareSnapshots(old, new)
+	portDiff := diff.ComparePorts(old, new)
+
+	if opts.EnvOnly {
+		fileDiff = diff.FileDiff{}
+		portDiff = diff.PortDiff{}
+	}
+	if opts.FilesOnly {
+		envDiff = diff.EnvDiff{}
+		portDiff = diff.PortDiff{}
+	}
+
+	if machineOutput() {
+		return emit(diffJSON{From: fromHash, To: toHash, Env: envDiff, Files: fileDiff, Ports: portDiff})
+	}
+
+	if envDiff.IsEmpty() && fileDiff.IsEmpty() && portDiff.IsEmpty() {
+		if !opts.NameOnly {
+			fmt.Println("✨ No differences found.")
+		}
+		return nil
+	}
+
+	if opts.NameOnly {
+		renderNames(os.Stdout, envDiff, fileDiff, portDiff)
+		return nil
+	}
 
-	if envDiff.IsEmpty() && fileDiff.IsEmpty() {
-		fmt.Println("✨ No differences found.")
+	if opts.Stat {
+		renderStat(os.Stdout, envDiff, fileDiff, portDiff)
 		return nil
 	}
 
 	// Render to string
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("🔍 Comparing %s..%s\n\n", fromCommit.ShortHash(), toCommit.ShortHash()))
+	sb.WriteString(title + "\n\n")
 
 	if !fileDiff.IsEmpty() {
 		diff.RenderFileDiff(&sb, fileDiff)
@@ -128,6 +178,17 @@ func DiffCommits(from, to string) error {
 		diff.RenderEnvDiff(&sb, envDiff)
 	}
 
+	if !portDiff.IsEmpty() {
+		diff.RenderPortDiff(&sb, portDiff)
+	}
+
+	if opts.Patch && !fileDiff.IsEmpty() {
+		sb.WriteString("\n")
+		if err := renderPatches(&sb, old.Files, new.Files, fileDiff, opts); err != nil {
+			return err
+		}
+	}
+
 	output := sb.String()
 
 	// interactive mode
@@ -139,3 +200,137 @@ func DiffCommits(from, to string) error {
 	fmt.Print(output)
 	return nil
 }
+
+// renderPatches writes the content changes of every changed file, loading both
+// versions from the blob store. JSON, YAML, TOML and INI files are compared by
+// key path unless opts.Text is set; everything else gets a unified line diff.
+func renderPatches(w io.Writer, oldFiles, newFiles map[string]string, fileDiff diff.FileDiff, opts DiffOptions) error {
+	context := opts.Context
+	if context < 0 {
+		context = diff.DefaultContext
+	}
+
+	paths := append(append(append([]string{}, fileDiff.Modified...), fileDiff.Added...), fileDiff.Removed...)
+	for _, path := range paths {
+		var oldContent, newContent []byte
+		if hash, ok := oldFiles[path]; ok {
+			data, err := store.LoadBlob(hash)
+			if err != nil {
+				return fmt.Errorf("load %s: %w", path, err)
+			}
+			oldContent = data
+		}
+		if hash, ok := newFiles[path]; ok {
+			data, err := store.LoadBlob(hash)
+			if err != nil {
+				return fmt.Errorf("load %s: %w", path, err)
+			}
+			newContent = data
+		}
+
+		renderPatch(w, path, oldContent, newContent, context, opts.Text)
+	}
+	return nil
+}
+
+// renderPatch writes the content changes of one file, by key path for
+// JSON, YAML, TOML and INI files unless text is set. .env files are always
+// compared by key with their values masked. Either content is nil if the file
+// does not exist on that side.
+func renderPatch(w io.Writer, path string, oldContent, newContent []byte, context int, text bool) {
+	if core.IsEnvFile(path) && !core.IsEnvTemplate(path) {
+		diff.RenderEnvChanges(w, path, diff.CompareEnvFile(oldContent, newContent))
+		fmt.Fprintln(w)
+		return
+	}
+
+	if diff.SemanticFormat(path) != "" && !text {
+		changes, err := diff.CompareStructured(path, oldContent, newContent)
+		if err == nil {
+			diff.RenderChanges(w, path, changes)
+			fmt.Fprintln(w)
+			return
+		}
+		// Unparseable config: fall back to a line diff
+	}
+
+	diff.RenderUnified(w, path, oldContent, newContent, context)
+	fmt.Fprintln(w)
+}
+
+// renderNames prints one changed file path, env key or port per line.
+func renderNames(w io.Writer, envDiff diff.EnvDiff, fileDiff diff.FileDiff, portDiff diff.PortDiff) {
+	for _, group := range [][]string{fileDiff.Added, fileDiff.Removed, fileDiff.Modified} {
+		for _, p := range group {
+			fmt.Fprintln(w, p)
+		}
+	}
+	for _, group := range [][]string{envDiff.Added, envDiff.Removed, envDiff.Changed} {
+		for _, k := range group {
+			fmt.Fprintln(w, k)
+		}
+	}
+	for _, group := range [][]string{portDiff.Opened, portDiff.Closed, portDiff.Changed} {
+		for _, p := range group {
+			fmt.Fprintln(w, p)
+		}
+	}
+}
+
+// renderStat prints a per-file, per-key and per-port summary followed by totals.
+func renderStat(w io.Writer, envDiff diff.EnvDiff, fileDiff diff.FileDiff, portDiff diff.PortDiff) {
+	type statLine struct {
+		name, status, color string
+	}
+	var lines []statLine
+	for _, p := range fileDiff.Added {
+		lines = append(lines, statLine{p, "added", "32"})
+	}
+	for _, p := range fileDiff.Removed {
+		lines = append(lines, statLine{p, "removed", "31"})
+	}
+	for _, p := range fileDiff.Modified {
+		lines = append(lines, statLine{p, "modified", "33"})
+	}
+	for _, k := range envDiff.Added {
+		lines = append(lines, statLine{k + " (env)", "added", "32"})
+	}
+	for _, k := range envDiff.Removed {
+		lines = append(lines, statLine{k + " (env)", "removed", "31"})
+	}
+	for _, k := range envDiff.Changed {
+		lines = append(lines, statLine{k + " (env)", "changed", "33"})
+	}
+	for _, p := range portDiff.Opened {
+		lines = append(lines, statLine{p, "opened", "32"})
+	}
+	for _, p := range portDiff.Closed {
+		lines = append(lines, statLine{p, "closed", "31"})
+	}
+	for _, p := range portDiff.Changed {
+		lines = append(lines, statLine{p, "changed", "33"})
+	}
+
+	width := 0
+	for _, l := range lines {
+		width = max(width, len(l.name))
+	}
+	for _, l := range lines {
+		fmt.Fprintf(w, " %-*s | \033[%sm%s\033[0m\n", width, l.name, l.color, l.status)
+	}
+
+	var totals []string
+	if files := len(fileDiff.Added) + len(fileDiff.Removed) + len(fileDiff.Modified); files > 0 {
+		totals = append(totals, fmt.Sprintf("%d file(s) changed (%d added, %d removed, %d modified)",
+			files, len(fileDiff.Added), len(fileDiff.Removed), len(fileDiff.Modified)))
+	}
+	if keys := len(envDiff.Added) + len(envDiff.Removed) + len(envDiff.Changed); keys > 0 {
+		totals = append(totals, fmt.Sprintf("%d env key(s) changed (%d added, %d removed, %d changed)",
+			keys, len(envDiff.Added), len(envDiff.Removed), len(envDiff.Changed)))
+	}
+	if ports := len(portDiff.Opened) + len(portDiff.Closed) + len(portDiff.Changed); ports > 0 {
+		totals = append(totals, fmt.Sprintf("%d port(s) changed (%d opened, %d closed, %d changed)",
+			ports, len(portDiff.Opened), len(portDiff.Closed), len(portDiff.Changed)))
+	}
+	fmt.Fprintf(w, " %s\n", strings.Join(totals, ", "))
+}
@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/diff"
+	"trace/internal/dotenv"
+	"trace/internal/store"
+)
+
+// DefaultEnvTemplate is the file `trace env template` writes and
+// `trace env check` reads by default.
+const DefaultEnvTemplate = ".env.example"
+
+// EnvTemplate writes a key-only template of the .env files recorded in rev
+// (default HEAD) to output. Comments are kept; values are left empty unless
+// they are obviously harmless or their key is listed under env_template.keep.
+func EnvTemplate(rev, output string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if rev == "" {
+		rev = core.HeadRef
+	}
+	if output == "" {
+		output = DefaultEnvTemplate
+	}
+
+	hash, err := store.ResolveCommit(rev)
+	if err != nil {
+		return err
+	}
+	commit, err := store.LoadCommit(hash)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", rev, err)
+	}
+
+	var paths []string
+	for path := range commit.Snapshot.Files {
+		if core.IsEnvFile(path) && !core.IsEnvTemplate(path) && filepath.Clean(path) != filepath.Clean(output) {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no .env files recorded in %s", rev)
+	}
+	// Base files first so their defaults win over local overrides
+	sort.Strings(paths)
+	core.SortByEnvPrecedence(paths)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Generated by `trace env template` from %s (%s).\n", rev, commit.ShortHash())
+	fmt.Fprintln(&buf, "# Values that may be secret are left empty; fill them in locally.")
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		content, err := store.LoadBlob(commit.Snapshot.Files[path])
+		if err != nil {
+			return fmt.Errorf("load %s: %w", path, err)
+		}
+		section := templateEnvFile(content, seen, cfg.EnvTemplate.Keep)
+		if len(bytes.TrimSpace(section)) == 0 {
+			continue
+		}
+		if len(paths) > 1 {
+			fmt.Fprintf(&buf, "\n# From %s\n", path)
+		} else {
+			buf.WriteString("\n")
+		}
+		buf.Write(section)
+	}
+
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", output, err)
+	}
+
+	fmt.Printf("📝 Wrote %s with %d key(s) from %s\n", output, len(seen), commit.ShortHash())
+	return nil
+}
+
+// templateEnvFile rewrites one .env file as a template, skipping keys already
+// in seen and adding the ones it writes. Values of keys matching keep are
+// copied as they are.
+func templateEnvFile(content []byte, seen map[string]bool, keep []string) []byte {
+	var buf bytes.Buffer
+	blank := true // Collapse runs of blank lines
+	for _, e := range dotenv.Parse(content).Entries {
+		switch {
+		case e.Comment != "":
+			buf.WriteString(e.Comment + "\n")
+			blank = false
+		case e.Key == "":
+			if !blank {
+				buf.WriteString("\n")
+			}
+			blank = true
+		case !seen[e.Key]:
+			seen[e.Key] = true
+			fmt.Fprintf(&buf, "%s=%s\n", e.Key, templateValue(e, keep))
+			blank = false
+		}
+	}
+	return buf.Bytes()
+}
+
+// templateValue returns the value to publish for an entry as written, or ""
+// unless its key matches keep or the value has a harmless shape.
+func templateValue(e dotenv.Entry, keep []string) string {
+	if matchesAny(e.Key, keep) {
+		return e.Raw
+	}
+	if diff.IsSecretKey(e.Key) || !harmlessValue(e.Value) {
+		return ""
+	}
+	return e.Raw
+}
+
+// maxHarmlessNumber bounds the length of numbers copied to templates: ports,
+// timeouts and counts are short, account IDs and PINs are not.
+const maxHarmlessNumber = 6
+
+// harmlessValue reports whether a value cannot be a secret: empty, a
+// boolean, a short number, or a localhost address or URL. URLs with a user,
+// a query string, a fragment or a ':' in the path may carry credentials
+// (?password=, #token=, /user:pass) and are not harmless.
+func harmlessValue(value string) bool {
+	switch strings.ToLower(value) {
+	case "", "true", "false", "yes", "no", "on", "off":
+		return true
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil && len(value) <= maxHarmlessNumber {
+		return true
+	}
+	if isLocalHost(value) {
+		return true
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil && isLocalHost(host) {
+		return true
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || !isLocalHost(u.Hostname()) {
+		return false
+	}
+	return u.User == nil && u.RawQuery == "" && !u.ForceQuery && !strings.Contains(value, "#") && !strings.Contains(u.Path, ":")
+}
+
+func isLocalHost(host string) bool {
+	switch host {
+	case "localhost", "127.0.0.1", "::1", "0.0.0.0":
+		return true
+	}
+	return false
+}
+
+// envCheckJSON is the schema of `trace env check --format json`.
+type envCheckJSON struct {
+	Template string   `json:"template"`
+	Missing  []string `json:"missing"` // In the template but not set locally
+	Extra    []string `json:"extra"`   // Set locally but not in the template
+}
+
+// EnvCheck reports keys present in the template but missing from the local
+// .env files. It returns an *ExitError with VerifyMissingKeys if any are missing.
+func EnvCheck(template string) error {
+	if template == "" {
+		template = DefaultEnvTemplate
+	}
+
+	content, err := os.ReadFile(template)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s not found (create it with 'trace env template')", template)
+		}
+		return fmt.Errorf("read %s: %w", template, err)
+	}
+	templateKeys := dotenv.Parse(content).Values()
+
+	local, err := localEnvKeys(template)
+	if err != nil {
+		return err
+	}
+
+	result := envCheckJSON{Template: template, Missing: []string{}, Extra: []string{}}
+	for key := range templateKeys {
+		if _, ok := local[key]; !ok {
+			result.Missing = append(result.Missing, key)
+		}
+	}
+	for key := range local {
+		if _, ok := templateKeys[key]; !ok {
+			result.Extra = append(result.Extra, key)
+		}
+	}
+	sort.Strings(result.Missing)
+	sort.Strings(result.Extra)
+
+	if machineOutput() {
+		if err := emit(result); err != nil {
+			return err
+		}
+	} else {
+		printEnvCheck(result)
+	}
+
+	if len(result.Missing) > 0 {
+		return &ExitError{Code: VerifyMissingKeys, Err: fmt.Errorf("%d key(s) from %s missing", len(result.Missing), template)}
+	}
+	return nil
+}
+
+func printEnvCheck(result envCheckJSON) {
+	if len(result.Missing) == 0 {
+		fmt.Printf("✅ All keys from %s are set\n", result.Template)
+	} else {
+		fmt.Printf("Missing keys (in %s but not set locally):\n", result.Template)
+		for _, k := range result.Missing {
+			fmt.Printf("  \033[31m✗ %s\033[0m\n", k)
+		}
+	}
+
+	if len(result.Extra) > 0 {
+		fmt.Printf("\nNot in %s (run 'trace env template' to update it):\n", result.Template)
+		for _, k := range result.Extra {
+			fmt.Printf("  \033[33m+ %s\033[0m\n", k)
+		}
+	}
+}
+
+// localEnvKeys reads the keys of all tracked .env files, excluding templates.
+func localEnvKeys(template string) (map[string]string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]string)
+	for _, path := range trackedFiles(cfg) {
+		if !core.IsEnvFile(path) || core.IsEnvTemplate(path) || path == filepath.Clean(template) {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		for key, value := range dotenv.Parse(content).Values() {
+			keys[key] = value
+		}
+	}
+	return keys, nil
+}
+
+// envWarnings parses every tracked .env file and returns its warnings as
+// "path:line: message".
+func envWarnings() ([]string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, path := range trackedFiles(cfg) {
+		if !core.IsEnvFile(path) {
+			continue
+		}
+		if ignored, _ := core.ShouldIgnore(path); ignored {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		for _, w := range dotenv.Parse(content).Warnings {
+			warnings = append(warnings, fmt.Sprintf("%s:%d: %s", path, w.Line, w.Message))
+		}
+	}
+	return warnings, nil
+}
+
+// printEnvWarnings prints parse warnings of the tracked .env files.
+func printEnvWarnings(warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Println("\n⚠️  .env parse warnings:")
+	for _, w := range warnings {
+		fmt.Printf("  \033[33m%s\033[0m\n", w)
+	}
+}
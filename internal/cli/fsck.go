@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+
+	"trace/internal/core"
+	"trace/internal/store"
+)
+
+// Fsck verifies the integrity of the object store and refs.
+// It returns an error if any issue is found so the command exits non-zero.
+func Fsck() error {
+	report, err := store.Fsck()
+	if err != nil {
+		return fmt.Errorf("fsck: %w", err)
+	}
+
+	fmt.Printf("🔎 Checked %d commit(s), %d blob(s), %d ref(s)\n",
+		report.Commits, report.Blobs, report.Refs)
+	if report.Unverified > 0 {
+		fmt.Printf("⚠️  %d encrypted blob(s) could not be verified: %s\n",
+			report.Unverified, report.UnverifiedReason)
+	}
+
+	if report.OK() {
+		fmt.Println("✨ No problems found.")
+		return nil
+	}
+
+	fmt.Println()
+	for _, issue := range report.Issues {
+		object := issue.Object
+		if len(object) == 64 {
+			object = core.ShortHash(object)
+		}
+		fmt.Printf("  \033[31m✗ [%s]\033[0m %s: %s\n", issue.Kind, object, issue.Detail)
+	}
+
+	return fmt.Errorf("found %d problem(s) in .trace", len(report.Issues))
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"trace/internal/core"
+	"trace/internal/store"
+)
+
+// DefaultReflogExpiry is how long reflog entries keep their commits alive.
+const DefaultReflogExpiry = "90 days ago"
+
+// GC removes commits and blobs that are no longer reachable from any ref.
+// Reflog entries older than expire (a date such as "30 days ago", "now" or
+// "never"; default DefaultReflogExpiry) are dropped first.
+func GC(dryRun bool, expire string) error {
+	var cutoff time.Time
+	if expire == "" {
+		expire = DefaultReflogExpiry
+	}
+	if expire != "never" {
+		t, err := store.ParseDate(expire, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid --expire: %w", err)
+		}
+		cutoff = t
+	}
+
+	result, err := store.GC(dryRun, cutoff)
+	if err != nil {
+		return fmt.Errorf("gc: %w", err)
+	}
+
+	if result.IsEmpty() {
+		fmt.Println("✨ Nothing to collect, all objects are reachable.")
+		return nil
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+
+	for _, hash := range result.Commits {
+		fmt.Printf("  \033[31m- [COMMIT]\033[0m %s\n", core.ShortHash(hash))
+	}
+	for _, hash := range result.Blobs {
+		fmt.Printf("  \033[31m- [BLOB]\033[0m   %s\n", core.ShortHash(hash))
+	}
+	for _, ref := range result.Dropped {
+		fmt.Printf("  \033[31m- [REFLOG]\033[0m %s (deleted)\n", ref)
+	}
+	if result.Expired > 0 {
+		fmt.Printf("\n⏳ %s %d reflog entries older than %s\n", verb, result.Expired, expire)
+	}
+
+	fmt.Printf("\n🧹 %s %d commit(s) and %d blob(s) (%s)\n",
+		verb, len(result.Commits), len(result.Blobs), formatBytes(result.Bytes))
+	return nil
+}
+
+// formatBytes renders a byte count in human readable units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
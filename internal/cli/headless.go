@@ -0,0 +1,382 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/diff"
+	"trace/internal/monitor"
+	"trace/internal/ports"
+	"trace/internal/store"
+	"trace/internal/watcher"
+)
+
+// WatchTimedOut is the exit code of `trace watch --headless --until-clean`
+// when --timeout expires before the environment is clean.
+const WatchTimedOut = 2
+
+// Event types of `trace watch --headless`.
+const (
+	EventReady          = "ready" // First event, with the initial state
+	EventFileAdded      = "file_added"
+	EventFileRemoved    = "file_removed"
+	EventFileModified   = "file_modified"
+	EventEnvAdded       = "env_added"
+	EventEnvRemoved     = "env_removed"
+	EventEnvChanged     = "env_changed"
+	EventProcessStarted = "process_started"
+	EventProcessStopped = "process_stopped"
+	EventPortOpened     = "port_opened"
+	EventPortClosed     = "port_closed"
+	EventHealthPassed   = "health_passed"
+	EventHealthFailed   = "health_failed"
+	EventAutoSnap       = "auto_snap"
+	EventError          = "error"
+	EventClean          = "clean"   // Last event with --until-clean
+	EventTimeout        = "timeout" // Last event when --timeout expires
+)
+
+// watchEvent is one line of `trace watch --headless`.
+type watchEvent struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Path     string    `json:"path,omitempty"`
+	Key      string    `json:"key,omitempty"`
+	Source   string    `json:"source,omitempty"` // Env file a key comes from, when several are tracked
+	PID      int32     `json:"pid,omitempty"`
+	Name     string    `json:"name,omitempty"` // Process or health check name
+	Port     int       `json:"port,omitempty"`
+	Protocol string    `json:"protocol,omitempty"`
+	Commit   string    `json:"commit,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Clean    *bool     `json:"clean,omitempty"` // On ready, clean and timeout events
+}
+
+// headless tracks what was last seen, to report changes as events.
+type headless struct {
+	cfg config.Config
+	enc *json.Encoder
+
+	files      core.Snapshot
+	procs      map[int32]string
+	liveEnv    map[string]map[string]string
+	ports      map[string]ports.Observed
+	health     map[string]bool
+	driftClean bool // No drift of the tracked files from HEAD and no policy violations
+	procsClean bool // Live environment as in HEAD, expected ports listening and health checks passing
+}
+
+// watchHeadless prints one JSON object per change on stdout instead of
+// running the TUI. With UntilClean it returns once the environment is clean;
+// with a Timeout it returns when the timeout expires, with an *ExitError if
+// the environment was expected to become clean.
+func watchHeadless(opts WatchOptions) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	h := &headless{cfg: cfg, enc: json.NewEncoder(os.Stdout)}
+
+	if err := h.scanFiles(true); err != nil {
+		return err
+	}
+	if err := h.scanProcesses(true); err != nil {
+		return err
+	}
+	clean := h.clean()
+	h.emit(watchEvent{Type: EventReady, Clean: &clean})
+	if opts.UntilClean && clean {
+		h.emit(watchEvent{Type: EventClean, Clean: &clean})
+		return nil
+	}
+
+	var changes <-chan watcher.Change
+	var watchErrs <-chan error
+	w, err := startWatcher()
+	if err == nil {
+		defer w.Close()
+		changes, watchErrs = w.Changes, w.Errors
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	// Auto-snapshots wait for the tracked files to settle
+	autoSnap := opts.AutoSnap || cfg.AutoSnap.Enabled
+	settle := time.NewTimer(autoSnapSettle(cfg))
+	settle.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			if ctx.Err() != context.DeadlineExceeded {
+				return nil // Interrupted
+			}
+			clean := h.clean()
+			h.emit(watchEvent{Type: EventTimeout, Clean: &clean})
+			if opts.UntilClean {
+				return &ExitError{Code: WatchTimedOut, Err: fmt.Errorf("environment not clean after %s", opts.Timeout)}
+			}
+			return nil
+
+		case <-ticker.C:
+			if changes == nil {
+				// No file notifications: poll drift too
+				prev := h.files
+				err = h.scanFiles(false)
+				if err == nil && autoSnap && !sameJSON(prev, h.files) {
+					settle.Reset(autoSnapSettle(h.cfg))
+				}
+			}
+			if err == nil {
+				err = h.scanProcesses(false)
+			}
+
+		case change, ok := <-changes:
+			if !ok {
+				changes = nil // Notifications stopped: poll instead
+				continue
+			}
+			if change.Config() {
+				h.cfg, err = config.Load()
+				if root, rootErr := core.FindProjectRoot(); rootErr == nil {
+					if files, filesErr := watchedFiles(root); filesErr == nil {
+						w.SetFiles(files)
+					}
+				}
+			}
+			if err == nil {
+				err = h.scanFiles(false)
+			}
+			if err == nil && (change.Refs() || change.Config()) {
+				// Port drift is relative to HEAD; health checks come from the config
+				err = h.scanProcesses(false)
+			}
+			if autoSnap && change.Files() {
+				settle.Reset(autoSnapSettle(h.cfg))
+			}
+
+		case err = <-watchErrs:
+		case <-settle.C:
+			h.autoSnap()
+		}
+
+		if err != nil {
+			h.emit(watchEvent{Type: EventError, Message: err.Error()})
+			continue
+		}
+		if opts.UntilClean && h.clean() {
+			clean := true
+			h.emit(watchEvent{Type: EventClean, Clean: &clean})
+			return nil
+		}
+	}
+}
+
+func (h *headless) emit(e watchEvent) {
+	e.Time = time.Now()
+	h.enc.Encode(e)
+}
+
+func (h *headless) clean() bool {
+	return h.driftClean && h.procsClean
+}
+
+// scanFiles reports changes of tracked files and env keys since the last
+// scan and checks drift from HEAD and the policy.
+func (h *headless) scanFiles(initial bool) error {
+	current, err := scanTracked(h.cfg, false)
+	if err != nil {
+		return fmt.Errorf("collect snapshot: %w", err)
+	}
+
+	if !initial {
+		envDiff, fileDiff := diff.CompareSnapshots(&h.files, &current)
+		for _, p := range fileDiff.Added {
+			h.emit(watchEvent{Type: EventFileAdded, Path: p})
+		}
+		for _, p := range fileDiff.Removed {
+			h.emit(watchEvent{Type: EventFileRemoved, Path: p})
+		}
+		for _, p := range fileDiff.Modified {
+			h.emit(watchEvent{Type: EventFileModified, Path: p})
+		}
+		for _, k := range envDiff.Added {
+			h.emit(watchEvent{Type: EventEnvAdded, Key: k, Source: envDiff.Sources[k]})
+		}
+		for _, k := range envDiff.Removed {
+			h.emit(watchEvent{Type: EventEnvRemoved, Key: k, Source: envDiff.Sources[k]})
+		}
+		for _, k := range envDiff.Changed {
+			h.emit(watchEvent{Type: EventEnvChanged, Key: k, Source: envDiff.Sources[k]})
+		}
+	}
+	h.files = current
+
+	head, err := core.GetHEAD()
+	if err != nil {
+		return fmt.Errorf("get HEAD: %w", err)
+	}
+	var base core.Snapshot
+	if head != "" {
+		headCommit, err := store.LoadCommit(head)
+		if err != nil {
+			return fmt.Errorf("load HEAD: %w", err)
+		}
+		base = headCommit.Snapshot
+		base.LiveEnv = nil // Checked with the processes
+	}
+	envDiff, fileDiff := diff.CompareSnapshots(&base, &current)
+	violations, err := checkPolicy()
+	if err != nil {
+		return err
+	}
+	h.driftClean = envDiff.IsEmpty() && fileDiff.IsEmpty() && len(violations) == 0
+	return nil
+}
+
+// scanProcesses reports processes started and stopped, live environment
+// keys changing, ports opened and closed (by project processes, or on
+// expected ports) and health checks changing state since the last scan.
+func (h *headless) scanProcesses(initial bool) error {
+	cwd, _ := os.Getwd()
+	// Processes that cannot be listed are left out, as in status
+	list, _ := monitor.GetProjectProcesses(cwd)
+	procs := make(map[int32]string, len(list))
+	for _, p := range list {
+		procs[p.PID] = p.Name
+	}
+
+	liveEnv := collectLiveEnv(h.cfg.LiveEnv, list)
+	headEnv, err := headLiveEnv()
+	if err != nil {
+		return err
+	}
+
+	observed, err := ports.Observe(h.cfg.ExpectedPorts, list)
+	if err != nil {
+		return fmt.Errorf("observe ports: %w", err)
+	}
+	expected := make(map[string]bool)
+	for _, e := range h.cfg.ExpectedPorts {
+		expected[fmt.Sprintf("%d/%s", e.Port, e.Proto())] = true
+	}
+	open := make(map[string]ports.Observed)
+	for _, o := range observed {
+		key := fmt.Sprintf("%d/%s", o.Port, o.Protocol)
+		if _, seen := open[key]; !seen && (o.Project || expected[key]) {
+			open[key] = o
+		}
+	}
+
+	results, err := checkHealth()
+	if err != nil {
+		return err
+	}
+	health := make(map[string]bool, len(results))
+	for _, r := range results {
+		health[r.Name] = r.Healthy
+	}
+
+	if !initial {
+		for _, pid := range sortedPIDs(procs) {
+			if _, ok := h.procs[pid]; !ok {
+				h.emit(watchEvent{Type: EventProcessStarted, PID: pid, Name: procs[pid]})
+			}
+		}
+		for _, pid := range sortedPIDs(h.procs) {
+			if _, ok := procs[pid]; !ok {
+				h.emit(watchEvent{Type: EventProcessStopped, PID: pid, Name: h.procs[pid]})
+			}
+		}
+		envDiff := compareLiveEnv(h.liveEnv, liveEnv)
+		for _, k := range envDiff.Added {
+			h.emit(watchEvent{Type: EventEnvAdded, Key: k})
+		}
+		for _, k := range envDiff.Removed {
+			h.emit(watchEvent{Type: EventEnvRemoved, Key: k})
+		}
+		for _, k := range envDiff.Changed {
+			h.emit(watchEvent{Type: EventEnvChanged, Key: k})
+		}
+		for _, o := range sortedPorts(open) {
+			if _, ok := h.ports[fmt.Sprintf("%d/%s", o.Port, o.Protocol)]; !ok {
+				h.emit(watchEvent{Type: EventPortOpened, Port: o.Port, Protocol: o.Protocol, PID: o.PID, Name: o.Name})
+			}
+		}
+		for _, o := range sortedPorts(h.ports) {
+			if _, ok := open[fmt.Sprintf("%d/%s", o.Port, o.Protocol)]; !ok {
+				h.emit(watchEvent{Type: EventPortClosed, Port: o.Port, Protocol: o.Protocol, PID: o.PID, Name: o.Name})
+			}
+		}
+		for _, r := range results {
+			if was, ok := h.health[r.Name]; ok && was == r.Healthy {
+				continue
+			}
+			if r.Healthy {
+				h.emit(watchEvent{Type: EventHealthPassed, Name: r.Name})
+			} else {
+				h.emit(watchEvent{Type: EventHealthFailed, Name: r.Name, Message: r.Detail})
+			}
+		}
+	}
+	h.procs, h.liveEnv, h.ports, h.health = procs, liveEnv, open, health
+
+	h.procsClean = compareLiveEnv(headEnv, liveEnv).IsEmpty() && len(unhealthy(results)) == 0
+	for _, issue := range ports.Check(h.cfg.ExpectedPorts, observed) {
+		if issue.Kind == ports.Missing {
+			h.procsClean = false
+		}
+	}
+	return nil
+}
+
+// autoSnap records an auto-snapshot and reports it.
+func (h *headless) autoSnap() {
+	commit, err := AutoSnap()
+	switch {
+	case err != nil:
+		h.emit(watchEvent{Type: EventError, Message: fmt.Sprintf("auto-snap: %v", err)})
+	case commit != nil:
+		h.emit(watchEvent{Type: EventAutoSnap, Commit: commit.Hash, Message: commit.Message})
+	}
+}
+
+func sortedPIDs(procs map[int32]string) []int32 {
+	pids := make([]int32, 0, len(procs))
+	for pid := range procs {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	return pids
+}
+
+func sortedPorts(open map[string]ports.Observed) []ports.Observed {
+	list := make([]ports.Observed, 0, len(open))
+	for _, o := range open {
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Port != list[j].Port {
+			return list[i].Port < list[j].Port
+		}
+		return list[i].Protocol < list[j].Protocol
+	})
+	return list
+}
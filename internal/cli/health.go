@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"trace/internal/config"
+	"trace/internal/monitor"
+)
+
+// checkHealth runs the health_checks of the config from the project root.
+// It returns no results if none are configured.
+func checkHealth() ([]monitor.HealthResult, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.HealthChecks) == 0 {
+		return nil, nil
+	}
+	cwd, _ := os.Getwd()
+	return monitor.RunHealthChecks(context.Background(), cwd, cfg.HealthChecks), nil
+}
+
+// unhealthy returns the failed health checks.
+func unhealthy(results []monitor.HealthResult) []monitor.HealthResult {
+	failed := []monitor.HealthResult{}
+	for _, r := range results {
+		if !r.Healthy {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// renderHealth prints one line per health check.
+func renderHealth(w io.Writer, results []monitor.HealthResult) {
+	for _, r := range results {
+		if r.Healthy {
+			fmt.Fprintf(w, "  \033[32m✓ %s\033[0m \033[90m%s %s (%s)\033[0m\n", r.Name, r.Kind, r.Target, r.Duration.Round(time.Millisecond))
+			continue
+		}
+		fmt.Fprintf(w, "  \033[31m✗ %s\033[0m %s\n", r.Name, r.Detail)
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"trace/internal/config"
 	"trace/internal/core"
@@ -9,7 +10,8 @@ import (
 )
 
 // Init initializes a new trace repository in the current directory.
-func Init() error {
+// With encrypt set, stored file contents are encrypted at rest.
+func Init(encrypt bool) error {
 	// Create directory structure
 	if err := store.Init(); err != nil {
 		return fmt.Errorf("init store: %w", err)
@@ -20,6 +22,12 @@ func Init() error {
 		return fmt.Errorf("init config: %w", err)
 	}
 
+	if encrypt {
+		if err := enableEncryption(); err != nil {
+			return fmt.Errorf("enable encryption: %w", err)
+		}
+	}
+
 	// Get current branch name for message
 	branch, _ := core.GetCurrentBranch()
 	if branch == "" {
@@ -29,7 +37,39 @@ func Init() error {
 	fmt.Printf("✨ Initialized empty Trace repository in .trace/\n")
 	fmt.Printf("   Branch: %s\n", branch)
 	fmt.Printf("   Tracking: .env\n")
+	if encrypt {
+		if os.Getenv(store.KeyEnv) != "" {
+			fmt.Printf("   Encryption: on (key from %s)\n", store.KeyEnv)
+		} else {
+			fmt.Printf("   Encryption: on (key in %s, keep it out of version control)\n", store.DefaultKeyFile)
+		}
+	}
 	fmt.Printf("\nRun 'trace snap \"initial state\"' to capture your first snapshot.\n")
 
 	return nil
 }
+
+// enableEncryption turns on blob encryption, creating a key file unless a key
+// is already available from TRACE_KEY or an existing key file.
+func enableEncryption() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if _, err := store.LoadKey(cfg); err != nil {
+		if os.Getenv(store.KeyEnv) != "" {
+			return err
+		}
+		key, err := store.GenerateKey()
+		if err != nil {
+			return err
+		}
+		if err := store.WriteKeyFile(store.KeyFilePath(cfg), key); err != nil {
+			return err
+		}
+	}
+
+	cfg.Encryption.Enabled = true
+	return config.Save(cfg)
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/diff"
+	"trace/internal/monitor"
+	"trace/internal/store"
+)
+
+// collectLiveEnv records hashed values of the shell and project process
+// environment keys selected in the config. procs are the project processes;
+// those whose environment cannot be read are skipped.
+func collectLiveEnv(cfg config.LiveEnv, procs []monitor.ProcessInfo) map[string]map[string]string {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	live := make(map[string]map[string]string)
+
+	if keys := selectEnv(os.Environ(), cfg.Shell); len(keys) > 0 {
+		live[core.ShellEnvSource] = keys
+	}
+
+	if len(cfg.Processes) > 0 {
+		// Lowest PID first so that the newest process of a name wins
+		procs = slices.Clone(procs)
+		sort.Slice(procs, func(i, j int) bool { return procs[i].PID < procs[j].PID })
+
+		self := int32(os.Getpid())
+		for _, p := range procs {
+			if p.PID == self {
+				continue
+			}
+			env, err := monitor.GetProcessEnv(p.PID)
+			if err != nil {
+				continue
+			}
+			keys := selectEnv(env, cfg.Processes)
+			if len(keys) == 0 {
+				continue
+			}
+			source := core.ProcessEnvPrefix + p.Name
+			if live[source] == nil {
+				live[source] = make(map[string]string)
+			}
+			for k, v := range keys {
+				live[source][k] = v
+			}
+		}
+	}
+
+	if len(live) == 0 {
+		return nil
+	}
+	return live
+}
+
+// liveEnvDrift compares the live environment of the shell and of procs with
+// the one recorded in HEAD. It is checked with the processes rather than
+// with the tracked files, as reading the environment of every project
+// process is too slow to repeat on each file change.
+func liveEnvDrift(procs []monitor.ProcessInfo) (diff.EnvDiff, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return diff.EnvDiff{}, err
+	}
+	// Without commits there is no drift, as in getDrift
+	if head, err := core.GetHEAD(); err != nil || head == "" {
+		return diff.EnvDiff{}, err
+	}
+	head, err := headLiveEnv()
+	if err != nil {
+		return diff.EnvDiff{}, err
+	}
+	return compareLiveEnv(head, collectLiveEnv(cfg.LiveEnv, procs)), nil
+}
+
+// headLiveEnv returns the live environment recorded in HEAD, or nil if
+// there are no commits.
+func headLiveEnv() (map[string]map[string]string, error) {
+	head, err := core.GetHEAD()
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD: %w", err)
+	}
+	if head == "" {
+		return nil, nil
+	}
+	commit, err := store.LoadCommit(head)
+	if err != nil {
+		return nil, fmt.Errorf("load HEAD: %w", err)
+	}
+	return commit.Snapshot.LiveEnv, nil
+}
+
+// compareLiveEnv compares two live environments, naming keys as
+// Snapshot.AllEnvKeys does.
+func compareLiveEnv(old, new map[string]map[string]string) diff.EnvDiff {
+	envDiff, _ := diff.CompareSnapshots(&core.Snapshot{LiveEnv: old}, &core.Snapshot{LiveEnv: new})
+	return envDiff
+}
+
+// withLiveEnv appends the live environment drift to the drift of the .env
+// files.
+func withLiveEnv(env, live diff.EnvDiff) diff.EnvDiff {
+	if live.IsEmpty() {
+		return env
+	}
+	env.Added = append(slices.Clone(env.Added), live.Added...)
+	env.Removed = append(slices.Clone(env.Removed), live.Removed...)
+	env.Changed = append(slices.Clone(env.Changed), live.Changed...)
+	return env
+}
+
+// selectEnv hashes the values of the KEY=VALUE entries whose key matches
+// one of the glob patterns.
+func selectEnv(environ, patterns []string) map[string]string {
+	keys := make(map[string]string)
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" || !matchesAny(key, patterns) {
+			continue
+		}
+		keys[key] = core.HashString(value)
+	}
+	return keys
+}
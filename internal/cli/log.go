@@ -9,33 +9,37 @@ import (
 	"trace/internal/store"
 )
 
-// Log displays the commit history.
-func Log(count int) error {
+// Log displays the commit history starting at rev (default HEAD).
+// A range A..B shows the commits reachable from B but not from A.
+func Log(rev string, count int) error {
 	head, err := core.GetHEAD()
 	if err != nil {
 		return fmt.Errorf("get HEAD: %w", err)
 	}
 
 	if head == "" {
+		if machineOutput() {
+			return emitList([]*core.Commit{})
+		}
 		fmt.Println("No commits yet. Run 'trace snap \"message\"' to create your first commit.")
 		return nil
 	}
 
-	history, err := store.GetCommitHistory(head)
+	history, err := commitLog(head, rev, count)
 	if err != nil {
-		return fmt.Errorf("get history: %w", err)
+		return err
 	}
 
-	if count > 0 && len(history) > count {
-		history = history[:count]
+	if machineOutput() {
+		return emitList(history)
 	}
 
 	branch, _ := core.GetCurrentBranch()
 
-	for i, c := range history {
+	for _, c := range history {
 		// Header
 		fmt.Printf("\033[33mcommit %s\033[0m", c.Hash)
-		if i == 0 {
+		if c.Hash == head {
 			fmt.Printf(" \033[36m(HEAD")
 			if branch != "" {
 				fmt.Printf(" -> %s", branch)
@@ -72,3 +76,34 @@ func Log(count int) error {
 
 	return nil
 }
+
+// commitLog returns the history starting at rev (default head) or the commits
+// of a range A..B, newest first and at most count if count is positive.
+func commitLog(head, rev string, count int) ([]*core.Commit, error) {
+	var history []*core.Commit
+	var err error
+	switch {
+	case rev == "":
+		history, err = store.GetCommitHistory(head)
+	case store.IsRange(rev):
+		from, to, rangeErr := store.ResolveRange(rev)
+		if rangeErr != nil {
+			return nil, rangeErr
+		}
+		history, err = store.GetCommitRange(from, to)
+	default:
+		start, resolveErr := store.ResolveCommit(rev)
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		history, err = store.GetCommitHistory(start)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get history: %w", err)
+	}
+
+	if count > 0 && len(history) > count {
+		history = history[:count]
+	}
+	return history, nil
+}
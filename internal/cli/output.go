@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"trace/internal/core"
+	"trace/internal/diff"
+	"trace/internal/monitor"
+	"trace/internal/policy"
+)
+
+// Output formats selectable with the global --format flag.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+var outputFormat = FormatText
+
+// SetOutputFormat selects how commands print their results.
+func SetOutputFormat(format string) error {
+	switch format {
+	case FormatText, FormatJSON, FormatNDJSON:
+		outputFormat = format
+		return nil
+	}
+	return fmt.Errorf("unknown format '%s' (use text, json or ndjson)", format)
+}
+
+// machineOutput reports whether results should be printed as JSON.
+func machineOutput() bool {
+	return outputFormat != FormatText
+}
+
+// emit writes v as indented JSON, or as a single line in NDJSON mode.
+func emit(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	if outputFormat == FormatJSON {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// emitList writes items as a JSON array, or one line per item in NDJSON mode.
+func emitList[T any](items []T) error {
+	if outputFormat == FormatNDJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return emit(items)
+}
+
+// statusJSON is the schema of `trace status --format json`.
+type statusJSON struct {
+	Branch    string                 `json:"branch"`
+	Head      string                 `json:"head"`
+	Detached  bool                   `json:"detached"`
+	Clean     bool                   `json:"clean"`
+	Env       diff.EnvDiff           `json:"env"`
+	Files     diff.FileDiff          `json:"files"`
+	Processes []monitor.ProcessInfo  `json:"processes"`
+	Policy    []policy.Violation     `json:"policy"`
+	Warnings  []string               `json:"warnings"` // .env parse warnings as path:line: message
+	Ports     PortStatus             `json:"ports"`
+	Health    []monitor.HealthResult `json:"health"`
+}
+
+// diffJSON is the schema of `trace diff --format json`.
+type diffJSON struct {
+	From  string        `json:"from"`
+	To    string        `json:"to"` // Empty when comparing with the working environment
+	Env   diff.EnvDiff  `json:"env"`
+	Files diff.FileDiff `json:"files"`
+	Ports diff.PortDiff `json:"ports"`
+}
+
+// branchJSON is the schema of each entry of `trace branch --format json`.
+type branchJSON struct {
+	Name    string `json:"name"`
+	Hash    string `json:"hash"`
+	Current bool   `json:"current"`
+}
+
+// newStatusJSON builds the status schema, normalizing empty lists.
+func newStatusJSON(envDiff diff.EnvDiff, fileDiff diff.FileDiff, procs []monitor.ProcessInfo, violations []policy.Violation, warnings []string, portStatus PortStatus, health []monitor.HealthResult) statusJSON {
+	branch, _ := core.GetCurrentBranch()
+	head, _ := core.GetHEAD()
+
+	processes := make([]monitor.ProcessInfo, 0, len(procs))
+	for _, p := range procs {
+		if p.Ports == nil {
+			p.Ports = []int{}
+		}
+		processes = append(processes, p)
+	}
+	if violations == nil {
+		violations = []policy.Violation{}
+	}
+	if warnings == nil {
+		warnings = []string{}
+	}
+	if health == nil {
+		health = []monitor.HealthResult{}
+	}
+
+	return statusJSON{
+		Branch:    branch,
+		Head:      head,
+		Detached:  branch == "" && head != "",
+		Clean:     envDiff.IsEmpty() && fileDiff.IsEmpty(),
+		Env:       envDiff,
+		Files:     fileDiff,
+		Processes: processes,
+		Policy:    violations,
+		Warnings:  warnings,
+		Ports:     portStatus,
+		Health:    health,
+	}
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/diff"
+	"trace/internal/dotenv"
+	"trace/internal/policy"
+)
+
+// checkPolicy evaluates .trace/policy against the tracked .env files and
+// configs. It returns no violations if the project has no policy.
+func checkPolicy() ([]policy.Violation, error) {
+	p, err := policy.Load()
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	in := policy.Input{
+		Env:   make(map[string]string),
+		Files: make(map[string]map[string]string),
+	}
+	for _, path := range trackedFiles(cfg) {
+		if ignored, _ := core.ShouldIgnore(path); ignored {
+			continue
+		}
+		// Templates such as .env.example document keys; they do not set them
+		if core.IsEnvTemplate(path) {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+
+		if core.IsEnvFile(path) {
+			for key, value := range dotenv.Parse(content).Values() {
+				in.Env[key] = value
+			}
+			continue
+		}
+		if diff.SemanticFormat(path) != "" {
+			// An unparseable config is reported as missing by the policy
+			if values, err := diff.Leaves(path, content); err == nil {
+				in.Files[path] = values
+			}
+		}
+	}
+
+	return p.Evaluate(in), nil
+}
+
+// renderViolations prints policy violations, one per line.
+func renderViolations(w io.Writer, violations []policy.Violation) {
+	for _, v := range violations {
+		fmt.Fprintf(w, "  \033[31m✗ %s\033[0m\n", v)
+	}
+}
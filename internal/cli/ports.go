@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/diff"
+	"trace/internal/monitor"
+	"trace/internal/ports"
+	"trace/internal/store"
+)
+
+// PortStatus compares the ports open now with HEAD and with expected_ports.
+type PortStatus struct {
+	Diff   diff.PortDiff `json:"diff"`
+	Issues []ports.Issue `json:"issues"`
+}
+
+// IsEmpty returns true if the ports match HEAD and expected_ports.
+func (s PortStatus) IsEmpty() bool {
+	return s.Diff.IsEmpty() && len(s.Issues) == 0
+}
+
+// GetPortStatus observes the listening ports and reports drift from HEAD and
+// from the expected_ports of the config.
+func GetPortStatus() (PortStatus, error) {
+	cwd, _ := os.Getwd()
+	// Processes that cannot be listed are left out, as in status
+	procs, _ := monitor.GetProjectProcesses(cwd)
+	return checkPortStatus(procs)
+}
+
+// checkPortStatus is GetPortStatus for the project processes procs, already
+// listed by the caller.
+func checkPortStatus(procs []monitor.ProcessInfo) (PortStatus, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return PortStatus{}, err
+	}
+
+	observed, err := ports.Observe(cfg.ExpectedPorts, procs)
+	if err != nil {
+		return PortStatus{}, fmt.Errorf("observe ports: %w", err)
+	}
+
+	status := PortStatus{Issues: ports.Check(cfg.ExpectedPorts, observed)}
+	if status.Issues == nil {
+		status.Issues = []ports.Issue{}
+	}
+
+	head, err := core.GetHEAD()
+	if err != nil {
+		return PortStatus{}, fmt.Errorf("get HEAD: %w", err)
+	}
+	if head != "" {
+		headCommit, err := store.LoadCommit(head)
+		if err != nil {
+			return PortStatus{}, fmt.Errorf("load HEAD: %w", err)
+		}
+		current := core.Snapshot{Ports: ports.Bindings(observed)}
+		status.Diff = diff.ComparePorts(&headCommit.Snapshot, &current)
+	}
+
+	return status, nil
+}
+
+// observePorts returns the ports of the project processes procs as recorded
+// in snapshots. Ports are left out if the sockets cannot be listed.
+func observePorts(cfg config.Config, procs []monitor.ProcessInfo) []core.PortBinding {
+	observed, err := ports.Observe(cfg.ExpectedPorts, procs)
+	if err != nil {
+		return nil
+	}
+	return ports.Bindings(observed)
+}
+
+// renderPortIssues prints expected_ports issues, one per line.
+func renderPortIssues(w io.Writer, issues []ports.Issue) {
+	for _, issue := range issues {
+		color := "31"
+		if issue.Kind == ports.Unexpected {
+			color = "33"
+		}
+		fmt.Fprintf(w, "  \033[%sm✗ [PORT %s]\033[0m %s\n", color, strings.ToUpper(issue.Kind), issue)
+	}
+}
+
+// portOwner is a process bound to a project port.
+type portOwner struct {
+	monitor.ProcessDetails
+	Project bool `json:"project"`
+}
+
+// portReport is the state of one project port, and the schema of each entry
+// of `trace ports --format json`.
+type portReport struct {
+	Port     int         `json:"port"`
+	Protocol string      `json:"protocol"`
+	Expected bool        `json:"expected"` // Listed in expected_ports, not only seen in the HEAD snapshot
+	Process  string      `json:"process,omitempty"`
+	Owners   []portOwner `json:"owners"`
+}
+
+// conflict reports whether a process outside the project holds the port.
+func (r portReport) conflict() bool {
+	for _, o := range r.Owners {
+		if !o.Project {
+			return true
+		}
+	}
+	return false
+}
+
+// Ports checks every expected port and every port recorded in the HEAD
+// snapshot, showing who holds it. Processes outside the project that hold
+// a project port are killed after confirmation, or right away with kill
+// once their PIDs are listed.
+func Ports(kill bool) error {
+	reports, err := checkProjectPorts()
+	if err != nil {
+		return err
+	}
+
+	if machineOutput() {
+		return emitList(reports)
+	}
+
+	if len(reports) == 0 {
+		fmt.Println("No project ports known.")
+		fmt.Println("  (list them under \"expected_ports\" in .trace/config.json, or snap while your services run)")
+		return nil
+	}
+
+	fmt.Println("🔌 Project ports:")
+	var squatters []portOwner
+	seen := make(map[int32]bool)
+	for _, r := range reports {
+		label := fmt.Sprintf("%d/%s", r.Port, r.Protocol)
+		switch {
+		case len(r.Owners) == 0:
+			fmt.Printf("  \033[90m· %-10s free\033[0m\n", label)
+		case !r.conflict():
+			fmt.Printf("  \033[32m✓ %-10s\033[0m %s\n", label, describeOwner(r.Owners[0].ProcessDetails))
+		default:
+			for _, o := range r.Owners {
+				if o.Project {
+					continue
+				}
+				fmt.Printf("  \033[31m✗ %-10s\033[0m held by %s outside the project\n", label, describeOwner(o.ProcessDetails))
+				if o.Cmdline != "" {
+					fmt.Printf("      cmd: %s\n", o.Cmdline)
+				}
+				if o.Cwd != "" {
+					fmt.Printf("      cwd: %s\n", o.Cwd)
+				}
+				if o.Container != "" {
+					fmt.Printf("      container: %s\n", o.Container)
+				}
+				if o.PID != 0 && !seen[o.PID] {
+					seen[o.PID] = true
+					squatters = append(squatters, o)
+				}
+			}
+		}
+	}
+
+	if len(squatters) == 0 {
+		return nil
+	}
+
+	interactive := isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
+	if !kill && !interactive {
+		fmt.Println("\n  (use \"trace ports --kill\" to stop them)")
+		return nil
+	}
+
+	fmt.Println()
+	if kill {
+		pids := make([]string, len(squatters))
+		for i, o := range squatters {
+			pids[i] = strconv.Itoa(int(o.PID))
+		}
+		noun := "process"
+		if len(pids) > 1 {
+			noun = "processes"
+		}
+		fmt.Printf("Killing %d %s: PID %s\n", len(pids), noun, strings.Join(pids, ", "))
+	}
+	reader := bufio.NewReader(os.Stdin)
+	for _, o := range squatters {
+		if !kill {
+			fmt.Printf("Kill %s? [y/N] ", describeOwner(o.ProcessDetails))
+			answer, _ := reader.ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				continue
+			}
+		}
+		if err := KillPID(o.PID); err != nil {
+			fmt.Printf("  \033[31m✗ %v\033[0m\n", err)
+			continue
+		}
+		fmt.Printf("  ✅ Stopped %s\n", describeOwner(o.ProcessDetails))
+	}
+	return nil
+}
+
+// checkProjectPorts collects the expected ports and those recorded in the
+// HEAD snapshot, and finds who holds each of them. Older commits are left
+// out: a port a project used once is no reason to kill whoever holds it now.
+func checkProjectPorts() ([]portReport, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*portReport)
+	var reports []*portReport
+	add := func(port int, protocol, process string, expected bool) {
+		key := fmt.Sprintf("%d/%s", port, protocol)
+		r, ok := byKey[key]
+		if !ok {
+			r = &portReport{Port: port, Protocol: protocol, Owners: []portOwner{}}
+			byKey[key] = r
+			reports = append(reports, r)
+		}
+		r.Expected = r.Expected || expected
+		if r.Process == "" {
+			r.Process = process
+		}
+	}
+
+	for _, e := range cfg.ExpectedPorts {
+		add(e.Port, e.Proto(), e.Process, true)
+	}
+
+	head, err := core.GetHEAD()
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD: %w", err)
+	}
+	if head != "" {
+		commit, err := store.LoadCommit(head)
+		if err != nil {
+			return nil, fmt.Errorf("load HEAD: %w", err)
+		}
+		for _, b := range commit.Snapshot.Ports {
+			add(b.Port, b.Protocol, b.Process, false)
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	// Processes that cannot be listed are left out, as in status
+	procs, _ := monitor.GetProjectProcesses(cwd)
+	observed, err := ports.Observe(cfg.ExpectedPorts, procs)
+	if err != nil {
+		return nil, fmt.Errorf("observe ports: %w", err)
+	}
+
+	details := make(map[int32]monitor.ProcessDetails)
+	for _, r := range reports {
+		for _, o := range ports.Owners(observed, r.Port, r.Protocol) {
+			d, ok := details[o.PID]
+			if !ok {
+				d = monitor.ProcessDetails{PID: o.PID, Name: o.Name}
+				if o.PID != 0 {
+					if full, err := monitor.GetProcessDetails(o.PID); err == nil {
+						d = full
+					}
+				}
+				details[o.PID] = d
+			}
+			r.Owners = append(r.Owners, portOwner{ProcessDetails: d, Project: o.Project})
+		}
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Port != reports[j].Port {
+			return reports[i].Port < reports[j].Port
+		}
+		return reports[i].Protocol < reports[j].Protocol
+	})
+
+	result := make([]portReport, len(reports))
+	for i, r := range reports {
+		result[i] = *r
+	}
+	return result, nil
+}
+
+func describeOwner(d monitor.ProcessDetails) string {
+	switch {
+	case d.PID == 0:
+		return "an unknown process (no permission to see it?)"
+	case d.Name == "":
+		return fmt.Sprintf("[%d]", d.PID)
+	default:
+		return fmt.Sprintf("%s [%d]", d.Name, d.PID)
+	}
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"trace/internal/core"
+)
+
+// Reflog shows every recorded movement of HEAD or a branch, newest first.
+func Reflog(ref string) error {
+	if ref == "" {
+		ref = core.HeadRef
+	}
+
+	entries, err := core.ReadReflog(ref)
+	if err != nil {
+		return fmt.Errorf("read reflog: %w", err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No reflog entries for %s.\n", ref)
+		return nil
+	}
+
+	for i, e := range entries {
+		hash := core.ShortHash(e.New)
+		if hash == "" {
+			hash = "0000000"
+		}
+
+		summary := e.Action
+		if e.Message != "" {
+			summary += ": " + e.Message
+		}
+
+		date := e.Timestamp
+		if t, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+			date = t.Format("Mon Jan 2 15:04:05 2006")
+		}
+
+		fmt.Printf("\033[33m%s\033[0m %s@{%d}: %s \033[90m(%s)\033[0m\n", hash, ref, i, summary, date)
+	}
+
+	return nil
+}
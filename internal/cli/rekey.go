@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"trace/internal/config"
+	"trace/internal/store"
+)
+
+// Rekey generates a new encryption key and re-encrypts every stored blob with it.
+// If encryption was not enabled yet, this enables it and encrypts existing blobs.
+func Rekey() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	key, err := store.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	count, err := store.Rekey(key)
+	if err != nil {
+		return fmt.Errorf("rekey: %w", err)
+	}
+
+	if !cfg.Encryption.Enabled {
+		cfg.Encryption.Enabled = true
+		if err := config.Save(cfg); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+	}
+
+	fmt.Printf("🔐 Re-encrypted %d blob(s)\n", count)
+	fmt.Printf("   New key written to %s\n", store.KeyFilePath(cfg))
+	if os.Getenv(store.KeyEnv) != "" {
+		fmt.Printf("\n⚠️  %s is set and still holds the old key.\n", store.KeyEnv)
+		fmt.Printf("   Update it from %s or unset it.\n", store.KeyFilePath(cfg))
+	}
+	return nil
+}
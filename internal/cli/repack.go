@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"fmt"
+
+	"trace/internal/store"
+)
+
+// Repack consolidates all objects into a single compressed packfile.
+func Repack() error {
+	result, err := store.Repack()
+	if err != nil {
+		return fmt.Errorf("repack: %w", err)
+	}
+
+	if result.Commits == 0 && result.Blobs == 0 {
+		fmt.Println("✨ Nothing to pack.")
+		return nil
+	}
+
+	fmt.Printf("📦 Packed %d commit(s) and %d blob(s)\n", result.Commits, result.Blobs)
+	fmt.Printf("   Size: %s -> %s\n", formatBytes(result.Before), formatBytes(result.After))
+	return nil
+}
@@ -12,20 +12,24 @@ import (
 
 	"trace/internal/config"
 	"trace/internal/core"
+	"trace/internal/diff"
 	"trace/internal/store"
 )
 
 // RestoreOptions configures the restore behavior.
 type RestoreOptions struct {
-	CommitRef string   // Specific commit to restore from (empty = HEAD)
+	CommitRef string   // Specific commit or range A..B to restore from (empty = HEAD)
 	Files     []string // Specific files to restore (empty = all)
 	NoBackup  bool     // Skip creating backup files
 }
 
 // Restore restores tracked files to a specific commit state.
+// With a range A..B, only the files added or modified between A and B are
+// restored, to their state at B.
 func Restore(opts RestoreOptions) error {
 	// Determine target commit
 	var targetHash string
+	var rangeBase *core.Snapshot
 	var err error
 
 	if opts.CommitRef == "" {
@@ -36,6 +40,17 @@ func Restore(opts RestoreOptions) error {
 		if targetHash == "" {
 			return fmt.Errorf("no commits yet")
 		}
+	} else if store.IsRange(opts.CommitRef) {
+		fromHash, toHash, err := store.ResolveRange(opts.CommitRef)
+		if err != nil {
+			return err
+		}
+		fromCommit, err := store.LoadCommit(fromHash)
+		if err != nil {
+			return fmt.Errorf("load commit: %w", err)
+		}
+		rangeBase = &fromCommit.Snapshot
+		targetHash = toHash
 	} else {
 		targetHash, err = store.ResolveCommit(opts.CommitRef)
 		if err != nil {
@@ -49,6 +64,16 @@ func Restore(opts RestoreOptions) error {
 		return fmt.Errorf("load commit: %w", err)
 	}
 
+	// Files available for restore
+	available := commit.Snapshot.Files
+	if rangeBase != nil {
+		fileDiff := diff.CompareFiles(rangeBase.Files, commit.Snapshot.Files)
+		available = make(map[string]string)
+		for _, path := range append(fileDiff.Added, fileDiff.Modified...) {
+			available[path] = commit.Snapshot.Files[path]
+		}
+	}
+
 	// Load config for backup setting
 	cfg, _ := config.Load()
 	createBackup := cfg.BackupOnRestore && !opts.NoBackup
@@ -66,14 +91,14 @@ func Restore(opts RestoreOptions) error {
 	// Interactive Mode: If no files specified and running in a terminal
 	if len(opts.Files) == 0 && isatty.IsTerminal(os.Stdout.Fd()) {
 		// Collect all available files from commit
-		var available []string
-		for f := range commit.Snapshot.Files {
-			available = append(available, f)
+		var choices []string
+		for f := range available {
+			choices = append(choices, f)
 		}
-		sort.Strings(available)
+		sort.Strings(choices)
 
 		// Launch TUI
-		selected, err := InteractiveRestoreSelection(available)
+		selected, err := InteractiveRestoreSelection(choices)
 		if err != nil {
 			return fmt.Errorf("interactive selection: %w", err)
 		}
@@ -85,7 +110,7 @@ func Restore(opts RestoreOptions) error {
 
 		// Use selected files
 		for _, f := range selected {
-			if hash, ok := commit.Snapshot.Files[f]; ok {
+			if hash, ok := available[f]; ok {
 				filesToRestore[f] = hash
 			}
 		}
@@ -94,7 +119,7 @@ func Restore(opts RestoreOptions) error {
 		// Restore specific files provided via args
 		for _, file := range opts.Files {
 			path := filepath.Clean(file)
-			hash, exists := commit.Snapshot.Files[path]
+			hash, exists := available[path]
 			if !exists {
 				fmt.Printf("⚠️  File not found in commit: %s\n", path)
 				continue
@@ -103,7 +128,7 @@ func Restore(opts RestoreOptions) error {
 		}
 	} else {
 		// Restore all tracked files (Script/Non-Interactive mode)
-		filesToRestore = commit.Snapshot.Files
+		filesToRestore = available
 	}
 
 	if len(filesToRestore) == 0 {
@@ -116,35 +141,12 @@ func Restore(opts RestoreOptions) error {
 
 	restored := 0
 	for path, hash := range filesToRestore {
-		// Load blob content
-		content, err := store.LoadBlob(hash)
-		if err != nil {
-			fmt.Printf("❌ Failed to load %s: %v\n", path, err)
-			continue
-		}
-
-		// Create backup if file exists and backup is enabled
-		if createBackup {
-			if _, err := os.Stat(path); err == nil {
-				backupPath := fmt.Sprintf("%s.backup.%d", path, time.Now().Unix())
-				if err := copyFile(path, backupPath); err == nil {
-					fmt.Printf("   📦 Backup: %s\n", backupPath)
-				}
-			}
+		backupPath, err := restoreFile(path, hash, createBackup)
+		if backupPath != "" {
+			fmt.Printf("   📦 Backup: %s\n", backupPath)
 		}
-
-		// Ensure directory exists
-		dir := filepath.Dir(path)
-		if dir != "." {
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				fmt.Printf("❌ Failed to create directory %s: %v\n", dir, err)
-				continue
-			}
-		}
-
-		// Write restored content
-		if err := os.WriteFile(path, content, 0644); err != nil {
-			fmt.Printf("❌ Failed to restore %s: %v\n", path, err)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
 			continue
 		}
 
@@ -164,6 +166,82 @@ func Restore(opts RestoreOptions) error {
 	return nil
 }
 
+// restoreFile writes the blob hash to path, first copying an existing file
+// to a timestamped backup if backup is set. It returns the backup path.
+func restoreFile(path, hash string, backup bool) (string, error) {
+	// Load blob content
+	content, err := store.LoadBlob(hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	// Create backup if file exists and backup is enabled
+	var backupPath string
+	if backup {
+		if _, err := os.Stat(path); err == nil {
+			candidate := fmt.Sprintf("%s.backup.%d", path, time.Now().Unix())
+			if err := copyFile(path, candidate); err == nil {
+				backupPath = candidate
+			}
+		}
+	}
+
+	// Ensure directory exists
+	dir := filepath.Dir(path)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return backupPath, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	// Write restored content
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return backupPath, fmt.Errorf("failed to restore %s: %w", path, err)
+	}
+	return backupPath, nil
+}
+
+// restoreCommit restores every tracked file of a commit without prompting
+// or printing, for the watch TUI. Backups follow the config and the restore
+// hooks run with their output discarded. It returns the restored paths.
+func restoreCommit(hash string) ([]string, error) {
+	commit, err := store.LoadCommit(hash)
+	if err != nil {
+		return nil, fmt.Errorf("load commit: %w", err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Hooks.PreRestore != "" {
+		if err := exec.Command("sh", "-c", cfg.Hooks.PreRestore).Run(); err != nil {
+			return nil, fmt.Errorf("pre-restore hook failed: %w", err)
+		}
+	}
+
+	var paths []string
+	for path := range commit.Snapshot.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var restored []string
+	for _, path := range paths {
+		if _, err := restoreFile(path, commit.Snapshot.Files[path], cfg.BackupOnRestore); err != nil {
+			return restored, err
+		}
+		restored = append(restored, path)
+	}
+
+	if cfg.Hooks.PostRestore != "" {
+		if err := exec.Command("sh", "-c", cfg.Hooks.PostRestore).Run(); err != nil {
+			return restored, fmt.Errorf("post-restore hook failed: %w", err)
+		}
+	}
+	return restored, nil
+}
+
 func runHook(name, command string) error {
 	fmt.Printf("🪝  Running %s hook: %s\n", name, command)
 	cmd := exec.Command("sh", "-c", command)
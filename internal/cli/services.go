@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/services"
+)
+
+// serviceSet loads the declared services and the project root they run in,
+// and checks that names refer to declared services. No names selects all.
+func serviceSet(names []string) (string, map[string]string, []string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if len(cfg.Services) == 0 {
+		return "", nil, nil, fmt.Errorf("no services declared (add them under \"services\" in .trace/config.json)")
+	}
+	root, err := core.FindProjectRoot()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if len(names) == 0 {
+		return root, cfg.Services, services.Names(cfg.Services), nil
+	}
+	for _, name := range names {
+		if _, ok := cfg.Services[name]; !ok {
+			return "", nil, nil, fmt.Errorf("unknown service '%s'", name)
+		}
+	}
+	return root, cfg.Services, names, nil
+}
+
+// Up starts the named services, or all declared services, that are not
+// already running.
+func Up(names []string) error {
+	root, declared, names, err := serviceSet(names)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, name := range names {
+		if pid, ok := services.Running(root, name); ok {
+			if !machineOutput() {
+				fmt.Printf("  • %s already running (PID %d)\n", name, pid)
+			}
+			continue
+		}
+		pid, err := services.Start(root, name, declared[name])
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "  \033[31m✗ %v\033[0m\n", err)
+			continue
+		}
+		if !machineOutput() {
+			fmt.Printf("  ✅ Started %s (PID %d)\n", name, pid)
+		}
+	}
+
+	if machineOutput() {
+		if err := emitList(services.List(root, declared)); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("\nLogs: .trace/%s/<name>.log\n", services.RunDir)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d service(s) failed to start", failed)
+	}
+	return nil
+}
+
+// Down stops the named services, or all declared services.
+func Down(names []string) error {
+	root, declared, names, err := serviceSet(names)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, name := range names {
+		pid, ok := services.Running(root, name)
+		if !ok {
+			continue
+		}
+		if err := services.Stop(root, name, services.DefaultStopTimeout); err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "  \033[31m✗ %v\033[0m\n", err)
+			continue
+		}
+		if !machineOutput() {
+			fmt.Printf("  ✅ Stopped %s (PID %d)\n", name, pid)
+		}
+	}
+
+	if machineOutput() {
+		if err := emitList(services.List(root, declared)); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d service(s) failed to stop", failed)
+	}
+	return nil
+}
+
+// Restart stops a service if it runs and starts it again.
+func Restart(name string) error {
+	pid, err := restartService(name)
+	if err != nil {
+		return err
+	}
+	if machineOutput() {
+		root, declared, _, err := serviceSet(nil)
+		if err != nil {
+			return err
+		}
+		return emitList(services.List(root, declared))
+	}
+	fmt.Printf("  ✅ Restarted %s (PID %d)\n", name, pid)
+	return nil
+}
+
+// restartService restarts a service silently, for the TUI.
+func restartService(name string) (int32, error) {
+	root, declared, _, err := serviceSet([]string{name})
+	if err != nil {
+		return 0, err
+	}
+	return services.Restart(root, name, declared[name], services.DefaultStopTimeout)
+}
+
+// stopService stops a service silently, for the TUI.
+func stopService(name string) error {
+	root, _, _, err := serviceSet([]string{name})
+	if err != nil {
+		return err
+	}
+	return services.Stop(root, name, services.DefaultStopTimeout)
+}
+
+// serviceStates returns the state of every declared service, or none if
+// the config declares none or cannot be read.
+func serviceStates() []services.Service {
+	root, declared, _, err := serviceSet(nil)
+	if err != nil {
+		return nil
+	}
+	return services.List(root, declared)
+}
+
+// serviceOf returns the service a process belongs to: services run in
+// process groups led by the PID recorded for them.
+func serviceOf(states []services.Service, pid int32) (services.Service, bool) {
+	pgid, err := services.GroupOf(pid)
+	if err != nil {
+		return services.Service{}, false
+	}
+	for _, s := range states {
+		if s.Running && s.PID == pgid {
+			return s, true
+		}
+	}
+	return services.Service{}, false
+}
@@ -1,14 +1,14 @@
 package cli
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"trace/internal/config"
 	"trace/internal/core"
+	"trace/internal/dotenv"
+	"trace/internal/monitor"
 	"trace/internal/store"
 )
 
@@ -18,16 +18,47 @@ func Snap(message string) error {
 		return fmt.Errorf("commit message required: trace snap \"your message\"")
 	}
 
+	commit, err := CreateSnapshot(message)
+	if err != nil {
+		return err
+	}
+	snapshot := commit.Snapshot
+
+	// Print summary
+	fmt.Printf("📸 Committed: %s\n", commit.ShortHash())
+	fmt.Printf("   Message: %s\n", message)
+	if len(snapshot.Files) > 0 {
+		fmt.Printf("   Files: %d tracked\n", len(snapshot.Files))
+	}
+	if len(snapshot.EnvKeys) > 0 {
+		fmt.Printf("   Env keys: %d captured\n", len(snapshot.EnvKeys))
+	}
+	if len(snapshot.Ports) > 0 {
+		fmt.Printf("   Ports: %d listening\n", len(snapshot.Ports))
+	}
+	if live := len(snapshot.AllEnvKeys()) - len(snapshot.EnvKeys); live > 0 {
+		fmt.Printf("   Live env keys: %d captured\n", live)
+	}
+	if warnings, _ := envWarnings(); len(warnings) > 0 {
+		printEnvWarnings(warnings)
+	}
+
+	return nil
+}
+
+// CreateSnapshot commits the current environment state on HEAD without
+// printing anything.
+func CreateSnapshot(message string) (*core.Commit, error) {
 	// Collect current state
 	snapshot, err := collectSnapshot()
 	if err != nil {
-		return fmt.Errorf("collect snapshot: %w", err)
+		return nil, fmt.Errorf("collect snapshot: %w", err)
 	}
 
 	// Get parent commit (current HEAD)
 	parent, err := core.GetHEAD()
 	if err != nil {
-		return fmt.Errorf("get HEAD: %w", err)
+		return nil, fmt.Errorf("get HEAD: %w", err)
 	}
 
 	// Create commit
@@ -35,42 +66,55 @@ func Snap(message string) error {
 
 	// Save commit
 	if err := store.SaveCommit(commit); err != nil {
-		return fmt.Errorf("save commit: %w", err)
+		return nil, fmt.Errorf("save commit: %w", err)
 	}
 
 	// Update HEAD
-	if err := core.SetHEAD(commit.Hash); err != nil {
-		return fmt.Errorf("update HEAD: %w", err)
-	}
-
-	// Print summary
-	fmt.Printf("📸 Committed: %s\n", commit.ShortHash())
-	fmt.Printf("   Message: %s\n", message)
-	if len(snapshot.Files) > 0 {
-		fmt.Printf("   Files: %d tracked\n", len(snapshot.Files))
-	}
-	if len(snapshot.EnvKeys) > 0 {
-		fmt.Printf("   Env keys: %d captured\n", len(snapshot.EnvKeys))
+	if err := core.SetHEAD(commit.Hash, "snap", message); err != nil {
+		return nil, fmt.Errorf("update HEAD: %w", err)
 	}
-
-	return nil
+	return commit, nil
 }
 
-// collectSnapshot captures the current environment state.
+// collectSnapshot captures the current environment state, saving the
+// contents of the tracked files as blobs.
 func collectSnapshot() (core.Snapshot, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return core.Snapshot{}, err
 	}
+	cwd, _ := os.Getwd()
+	// Processes that cannot be listed are left out, as in status
+	procs, _ := monitor.GetProjectProcesses(cwd)
+	return captureSnapshot(cfg, procs, true)
+}
 
-	snapshot := core.Snapshot{
-		EnvKeys: make(map[string]string),
-		Files:   make(map[string]string),
+// captureSnapshot captures the tracked files and the live environment and
+// ports of the project processes procs. File contents are saved as blobs
+// only if save is set, so that read-only commands never write objects (nor
+// need the encryption key).
+func captureSnapshot(cfg config.Config, procs []monitor.ProcessInfo, save bool) (core.Snapshot, error) {
+	snapshot, err := scanTracked(cfg, save)
+	if err != nil {
+		return core.Snapshot{}, err
 	}
+	snapshot.LiveEnv = collectLiveEnv(cfg.LiveEnv, procs)
+	snapshot.Ports = observePorts(cfg, procs)
+	return snapshot, nil
+}
 
-	for _, file := range cfg.TrackedFiles {
-		path := filepath.Clean(file)
+// scanTracked captures the tracked files, but neither the live environment
+// nor the ports, which depend on processes. File contents are saved as
+// blobs only if save is set; otherwise they are just hashed.
+func scanTracked(cfg config.Config, save bool) (core.Snapshot, error) {
+	snapshot := core.Snapshot{
+		EnvKeys:  make(map[string]string),
+		Files:    make(map[string]string),
+		EnvFiles: make(map[string]map[string]string),
+	}
 
+	// Later env files override earlier ones in the effective view
+	for _, path := range trackedFiles(cfg) {
 		ignored, _ := core.ShouldIgnore(path)
 		if ignored {
 			continue
@@ -86,46 +130,37 @@ func collectSnapshot() (core.Snapshot, error) {
 		}
 
 		// Store blob for restore capability
-		hash, err := store.SaveBlob(content)
-		if err != nil {
-			return core.Snapshot{}, fmt.Errorf("save blob for %s: %w", path, err)
+		hash := core.HashContent(content)
+		if save {
+			if hash, err = store.SaveBlob(content); err != nil {
+				return core.Snapshot{}, fmt.Errorf("save blob for %s: %w", path, err)
+			}
 		}
 
 		snapshot.Files[path] = hash
 
 		// If it's a .env file, also capture keys
-		if strings.HasSuffix(path, ".env") || strings.Contains(path, ".env.") {
-			keys := parseEnvKeys(content)
-			for key, value := range keys {
+		if core.IsEnvFile(path) {
+			keys := make(map[string]string)
+			for key, value := range dotenv.Parse(content).Values() {
 				// Store hash of value, not the value itself
-				snapshot.EnvKeys[key] = core.HashString(value)
+				keys[key] = core.HashString(value)
+				snapshot.EnvKeys[key] = keys[key]
 			}
+			snapshot.EnvFiles[path] = keys
 		}
 	}
 
 	return snapshot, nil
 }
 
-// parseEnvKeys extracts key-value pairs from .env file content.
-func parseEnvKeys(content []byte) map[string]string {
-	keys := make(map[string]string)
-	scanner := bufio.NewScanner(strings.NewReader(string(content)))
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Parse KEY=VALUE
-		if idx := strings.Index(line, "="); idx > 0 {
-			key := strings.TrimSpace(line[:idx])
-			value := strings.TrimSpace(line[idx+1:])
-			keys[key] = value
-		}
+// trackedFiles returns the cleaned paths of the tracked files, with env files
+// ordered from lowest to highest precedence.
+func trackedFiles(cfg config.Config) []string {
+	paths := make([]string, len(cfg.TrackedFiles))
+	for i, file := range cfg.TrackedFiles {
+		paths[i] = filepath.Clean(file)
 	}
-
-	return keys
+	core.SortByEnvPrecedence(paths)
+	return paths
 }
@@ -5,14 +5,40 @@ import (
 	"os"
 	"strings"
 
+	"trace/internal/config"
 	"trace/internal/core"
 	"trace/internal/diff"
 	"trace/internal/monitor"
+	"trace/internal/policy"
 	"trace/internal/store"
 )
 
 // Status shows the current environment drift from HEAD.
 func Status() error {
+	if machineOutput() {
+		envDiff, fileDiff, procs, err := GetStatus()
+		if err != nil && err.Error() != "no commits" {
+			return err
+		}
+		violations, err := checkPolicy()
+		if err != nil {
+			return err
+		}
+		warnings, err := envWarnings()
+		if err != nil {
+			return err
+		}
+		portStatus, err := GetPortStatus()
+		if err != nil {
+			return err
+		}
+		health, err := checkHealth()
+		if err != nil {
+			return err
+		}
+		return emit(newStatusJSON(envDiff, fileDiff, procs, violations, warnings, portStatus, health))
+	}
+
 	// Get current branch
 	branch, _ := core.GetCurrentBranch()
 	if branch != "" {
@@ -24,11 +50,32 @@ func Status() error {
 		}
 	}
 
+	violations, err := checkPolicy()
+	if err != nil {
+		return err
+	}
+	warnings, err := envWarnings()
+	if err != nil {
+		return err
+	}
+	portStatus, err := GetPortStatus()
+	if err != nil {
+		return err
+	}
+	health, err := checkHealth()
+	if err != nil {
+		return err
+	}
+
 	envDiff, fileDiff, procs, err := GetStatus()
 	if err != nil {
 		if err.Error() == "no commits" {
 			fmt.Println("\nNo commits yet.")
 			fmt.Println("  (use \"trace snap <message>\" to create your first commit)")
+			printPolicyViolations(violations)
+			printEnvWarnings(warnings)
+			printPortStatus(portStatus)
+			printHealth(health)
 			return nil
 		}
 		return err
@@ -50,6 +97,11 @@ func Status() error {
 		}
 	}
 
+	printPolicyViolations(violations)
+	printEnvWarnings(warnings)
+	printPortStatus(portStatus)
+	printHealth(health)
+
 	if len(procs) > 0 {
 		fmt.Println("\n🚀 Active Processes:")
 		for _, p := range procs {
@@ -68,6 +120,61 @@ func Status() error {
 	return nil
 }
 
+func printPortStatus(status PortStatus) {
+	if status.IsEmpty() {
+		return
+	}
+	fmt.Println("\n🔌 Ports:")
+	renderPortIssues(os.Stdout, status.Issues)
+	diff.RenderPortDiff(os.Stdout, status.Diff)
+}
+
+func printHealth(results []monitor.HealthResult) {
+	if len(results) == 0 {
+		return
+	}
+	fmt.Println("\n🩺 Health:")
+	renderHealth(os.Stdout, results)
+}
+
+func printPolicyViolations(violations []policy.Violation) {
+	if len(violations) == 0 {
+		return
+	}
+	fmt.Println("\n🚫 Policy violations (.trace/policy):")
+	renderViolations(os.Stdout, violations)
+}
+
+// getDrift compares the tracked files and their env keys with HEAD, without
+// saving blobs or looking at processes, ports and the live environment.
+func getDrift() (diff.EnvDiff, diff.FileDiff, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return diff.EnvDiff{}, diff.FileDiff{}, err
+	}
+	head, err := core.GetHEAD()
+	if err != nil {
+		return diff.EnvDiff{}, diff.FileDiff{}, fmt.Errorf("get HEAD: %w", err)
+	}
+	if head == "" {
+		return diff.EnvDiff{}, diff.FileDiff{}, fmt.Errorf("no commits")
+	}
+
+	headCommit, err := store.LoadCommit(head)
+	if err != nil {
+		return diff.EnvDiff{}, diff.FileDiff{}, fmt.Errorf("load HEAD: %w", err)
+	}
+	current, err := scanTracked(cfg, false)
+	if err != nil {
+		return diff.EnvDiff{}, diff.FileDiff{}, fmt.Errorf("collect snapshot: %w", err)
+	}
+
+	base := headCommit.Snapshot
+	base.LiveEnv = nil
+	envDiff, fileDiff := diff.CompareSnapshots(&base, &current)
+	return envDiff, fileDiff, nil
+}
+
 // GetStatus returns the current drift and active processes.
 func GetStatus() (diff.EnvDiff, diff.FileDiff, []monitor.ProcessInfo, error) {
 	// Get HEAD commit
@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/diff"
+	"trace/internal/monitor"
+	"trace/internal/policy"
+	"trace/internal/store"
+)
+
+// Exit codes of `trace verify`. When several checks fail the most specific
+// one wins: missing keys, then missing processes, then policy, then failed
+// health checks, then drift.
+const (
+	VerifyClean            = 0
+	VerifyDrift            = 2
+	VerifyMissingKeys      = 3
+	VerifyMissingProcesses = 4
+	VerifyPolicy           = 5
+	VerifyUnhealthy        = 6
+)
+
+// VerifyOptions configures what verify checks and ignores.
+type VerifyOptions struct {
+	Ref              string   // Revision to verify against (empty = HEAD)
+	IgnoreKeys       []string // Env keys to ignore (glob patterns)
+	IgnoreFiles      []string // Tracked files to ignore (glob patterns)
+	RequireProcesses []string // Process names that must be running
+}
+
+// ExitError is returned by commands that must exit with a specific code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyResult is the outcome of verifying the working environment.
+type VerifyResult struct {
+	Ref              string                 `json:"ref"`
+	Code             int                    `json:"code"`
+	Env              diff.EnvDiff           `json:"env"`
+	Files            diff.FileDiff          `json:"files"`
+	MissingKeys      []string               `json:"missing_keys"`
+	MissingProcesses []string               `json:"missing_processes"`
+	Policy           []policy.Violation     `json:"policy"`
+	Unhealthy        []monitor.HealthResult `json:"unhealthy"` // Failed health_checks
+}
+
+// Verify compares the working environment with a commit and returns an
+// *ExitError carrying a non-zero code if it does not match.
+func Verify(opts VerifyOptions) error {
+	result, err := RunVerify(opts)
+	if err != nil {
+		return err
+	}
+
+	if machineOutput() {
+		if err := emit(result); err != nil {
+			return err
+		}
+	} else {
+		printVerify(result)
+	}
+
+	switch result.Code {
+	case VerifyClean:
+		return nil
+	case VerifyMissingKeys:
+		missing := len(result.MissingKeys)
+		for _, v := range result.Policy {
+			if v.Kind == policy.Missing {
+				missing++
+			}
+		}
+		return &ExitError{Code: result.Code, Err: fmt.Errorf("verify failed: %d required key(s) missing", missing)}
+	case VerifyMissingProcesses:
+		return &ExitError{Code: result.Code, Err: fmt.Errorf("verify failed: %d required process(es) not running", len(result.MissingProcesses))}
+	case VerifyPolicy:
+		return &ExitError{Code: result.Code, Err: fmt.Errorf("verify failed: %d policy violation(s)", len(result.Policy))}
+	case VerifyUnhealthy:
+		return &ExitError{Code: result.Code, Err: fmt.Errorf("verify failed: %d health check(s) failed", len(result.Unhealthy))}
+	default:
+		return &ExitError{Code: result.Code, Err: fmt.Errorf("verify failed: environment drifted from %s", result.Ref)}
+	}
+}
+
+// RunVerify performs the checks of Verify without printing anything.
+// Keys recorded in the commit but absent from the working environment count
+// as missing required keys, as do keys and files .trace/policy requires;
+// added or changed keys and files count as drift. Every configured health
+// check must pass.
+func RunVerify(opts VerifyOptions) (VerifyResult, error) {
+	ref := opts.Ref
+	if ref == "" {
+		ref = core.HeadRef
+	}
+
+	hash, err := store.ResolveCommit(ref)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	commit, err := store.LoadCommit(hash)
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("load %s: %w", ref, err)
+	}
+
+	// Verify is a read-only gate: hash the tracked files without saving blobs
+	cfg, err := config.Load()
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	cwd, _ := os.Getwd()
+	procs, procsErr := monitor.GetProjectProcesses(cwd)
+	current, err := captureSnapshot(cfg, procs, false)
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("collect snapshot: %w", err)
+	}
+
+	envDiff, fileDiff := diff.CompareSnapshots(&commit.Snapshot, &current)
+	envDiff = diff.EnvDiff{
+		Added:   filterIgnored(envDiff.Added, opts.IgnoreKeys),
+		Removed: filterIgnored(envDiff.Removed, opts.IgnoreKeys),
+		Changed: filterIgnored(envDiff.Changed, opts.IgnoreKeys),
+		Sources: envDiff.Sources,
+	}
+	fileDiff = diff.FileDiff{
+		Added:    filterIgnored(fileDiff.Added, opts.IgnoreFiles),
+		Removed:  filterIgnored(fileDiff.Removed, opts.IgnoreFiles),
+		Modified: filterIgnored(fileDiff.Modified, opts.IgnoreFiles),
+	}
+
+	result := VerifyResult{
+		Ref:              ref,
+		Env:              envDiff,
+		Files:            fileDiff,
+		MissingKeys:      append([]string{}, envDiff.Removed...),
+		MissingProcesses: []string{},
+	}
+
+	violations, err := checkPolicy()
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	result.Policy = []policy.Violation{}
+	missingPolicy := false
+	for _, v := range violations {
+		if v.File == "" && matchesAny(v.Key, opts.IgnoreKeys) || v.File != "" && matchesAny(v.File, opts.IgnoreFiles) {
+			continue
+		}
+		result.Policy = append(result.Policy, v)
+		missingPolicy = missingPolicy || v.Kind == policy.Missing
+	}
+
+	if len(opts.RequireProcesses) > 0 {
+		if procsErr != nil {
+			return VerifyResult{}, fmt.Errorf("list processes: %w", procsErr)
+		}
+		result.MissingProcesses = missingProcesses(opts.RequireProcesses, procs)
+	}
+
+	health, err := checkHealth()
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	result.Unhealthy = unhealthy(health)
+
+	switch {
+	case len(result.MissingKeys) > 0 || missingPolicy:
+		result.Code = VerifyMissingKeys
+	case len(result.MissingProcesses) > 0:
+		result.Code = VerifyMissingProcesses
+	case len(result.Policy) > 0:
+		result.Code = VerifyPolicy
+	case len(result.Unhealthy) > 0:
+		result.Code = VerifyUnhealthy
+	case !envDiff.IsEmpty() || !fileDiff.IsEmpty():
+		result.Code = VerifyDrift
+	default:
+		result.Code = VerifyClean
+	}
+
+	return result, nil
+}
+
+func printVerify(result VerifyResult) {
+	if result.Code == VerifyClean {
+		fmt.Printf("✅ Environment matches %s\n", result.Ref)
+		return
+	}
+
+	fmt.Printf("❌ Environment does not match %s\n\n", result.Ref)
+
+	if len(result.MissingKeys) > 0 {
+		fmt.Println("Missing required keys:")
+		for _, k := range result.MissingKeys {
+			fmt.Printf("  \033[31m✗ %s\033[0m\n", k)
+		}
+		fmt.Println()
+	}
+
+	if len(result.Policy) > 0 {
+		fmt.Println("Policy violations (.trace/policy):")
+		renderViolations(os.Stdout, result.Policy)
+		fmt.Println()
+	}
+
+	if len(result.MissingProcesses) > 0 {
+		fmt.Println("Required processes not running:")
+		for _, name := range result.MissingProcesses {
+			fmt.Printf("  \033[31m✗ %s\033[0m\n", name)
+		}
+		fmt.Println()
+	}
+
+	if len(result.Unhealthy) > 0 {
+		fmt.Println("Failed health checks:")
+		renderHealth(os.Stdout, result.Unhealthy)
+		fmt.Println()
+	}
+
+	if !result.Files.IsEmpty() {
+		diff.RenderFileDiff(os.Stdout, result.Files)
+	}
+	if !result.Env.IsEmpty() {
+		diff.RenderEnvDiff(os.Stdout, result.Env)
+	}
+}
+
+// filterIgnored drops names matching any of the glob patterns.
+func filterIgnored(names, patterns []string) []string {
+	if len(patterns) == 0 {
+		return names
+	}
+	var kept []string
+	for _, name := range names {
+		if !matchesAny(name, patterns) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return true
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// missingProcesses returns the required names that match no running process.
+// Names are compared case-insensitively.
+func missingProcesses(required []string, procs []monitor.ProcessInfo) []string {
+	missing := []string{}
+	for _, name := range required {
+		found := false
+		for _, p := range procs {
+			if strings.EqualFold(p.Name, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -2,14 +2,23 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"trace/internal/config"
+	"trace/internal/core"
 	"trace/internal/diff"
 	"trace/internal/monitor"
+	"trace/internal/policy"
+	"trace/internal/services"
+	"trace/internal/watcher"
 )
 
 var (
@@ -21,30 +30,130 @@ var (
 	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))            // Pink
 )
 
+// WatchOptions configures watch.
+type WatchOptions struct {
+	Interval time.Duration // How often processes, ports and health are refreshed
+	AutoSnap bool          // Snapshot to auto/<branch> when tracked files settle (also enabled by auto_snap in the config)
+
+	Headless   bool          // Print changes as NDJSON events instead of running the TUI
+	UntilClean bool          // Headless: exit once the environment is clean
+	Timeout    time.Duration // Headless: exit after this long, failing if UntilClean was not met
+}
+
 type model struct {
-	interval time.Duration
-	envDiff  diff.EnvDiff
+	interval time.Duration    // How often processes, ports and health are refreshed
+	watcher  *watcher.Watcher // Nil if file notifications are unavailable; drift is then polled
+	autoSnap bool
+	settle   time.Duration // Quiet time before an auto-snapshot
+	autoSeq  int           // Bumped on every file change; only the latest settle timer snaps
+	lastAuto *core.Commit  // Latest auto-snapshot taken by this session
+	envDiff  diff.EnvDiff  // Keys of the .env files
 	fileDiff diff.FileDiff
+	liveDiff diff.EnvDiff // Keys of the live environment, refreshed with the processes
 	procs    []monitor.ProcessInfo
-	err      error
-	cursor   int
+	policy   []policy.Violation
+	ports    PortStatus
+	health   []monitor.HealthResult
+	services []services.Service
+	driftErr error
+	procErr  error
+	cursor   int    // Selected row: services first, then processes
 	message  string // Status message
+
+	pane          pane
+	width, height int
+
+	driftCursor int
+	patch       viewport.Model // Content diff of the file selected in the drift pane
+	patchPath   string         // Set while the diff is open
+
+	head          string
+	history       []*core.Commit
+	historyCursor int
+	historyErr    error
+
+	detailPID  int32 // Process shown in the process pane
+	detail     *monitor.ProcessStats
+	detailErr  error
+	cpuSample  cpuSample
+	cpuPercent float64
+	cpuLive    bool // cpuPercent is measured between refreshes, not averaged since start
+
+	prompting bool // Typing a snapshot message
+	snapInput textinput.Model
 }
 
 type tickMsg time.Time
-type statusMsg struct {
+
+// driftMsg carries the sections that depend on the tracked files and refs.
+type driftMsg struct {
 	envDiff  diff.EnvDiff
 	fileDiff diff.FileDiff
+	policy   []policy.Violation
+	err      error
+}
+
+// processMsg carries the sections that depend on running processes.
+type processMsg struct {
 	procs    []monitor.ProcessInfo
+	liveDiff diff.EnvDiff
+	ports    PortStatus
+	health   []monitor.HealthResult
+	services []services.Service
 	err      error
 }
 
+// changeMsg reports that tracked files, refs or settings changed on disk.
+type changeMsg watcher.Change
+
+// watchErrMsg reports a failure of the file watcher.
+type watchErrMsg struct{ err error }
+
+// settledMsg fires when the settle time after file change seq has passed.
+type settledMsg struct{ seq int }
+
+// autoSnapMsg reports an auto-snapshot, or nil if nothing had changed.
+type autoSnapMsg struct {
+	commit *core.Commit
+	err    error
+}
+
+// actionMsg reports a service stopped or restarted, or a process killed.
+type actionMsg struct {
+	done string // What was done, on success
+	err  error  // What failed, e.g. "stopping web: ..."
+	pid  int32  // Process killed, if any
+}
+
 // clearMsg clears the status message
 type clearMsg struct{}
 
-func Watch(interval time.Duration) error {
-	p := tea.NewProgram(initialModel(interval))
-	_, err := p.Run()
+// Watch shows drift, processes and ports live, with tabs for the content
+// diff of changed files, the history and the details of a process. Drift is refreshed when the
+// tracked files or refs change on disk; processes, ports and health checks
+// every interval. With auto-snapshots, the environment is recorded on
+// auto/<branch> once tracked files settle after a change. Headless, the
+// changes are printed as NDJSON events instead.
+func Watch(opts WatchOptions) error {
+	if opts.Headless {
+		return watchHeadless(opts)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	m := initialModel(opts.Interval)
+	m.autoSnap = opts.AutoSnap || cfg.AutoSnap.Enabled
+	m.settle = autoSnapSettle(cfg)
+	if w, err := startWatcher(); err == nil {
+		m.watcher = w
+		defer w.Close()
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	_, err = p.Run()
 	return err
 }
 
@@ -54,59 +163,226 @@ func initialModel(interval time.Duration) model {
 	}
 }
 
+// startWatcher watches the tracked files and .trace of the project.
+func startWatcher() (*watcher.Watcher, error) {
+	root, err := core.FindProjectRoot()
+	if err != nil {
+		return nil, err
+	}
+	files, err := watchedFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	return watcher.New(root, files, watcher.DefaultDebounce)
+}
+
+// watchedFiles returns the tracked files relative to root. Tracked paths
+// are read relative to the working directory, which may be below root.
+func watchedFiles(root string) ([]string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	cwd, _ := os.Getwd()
+	var files []string
+	for _, path := range trackedFiles(cfg) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			files = append(files, rel)
+		}
+	}
+	return files, nil
+}
+
 func (m model) Init() tea.Cmd {
-	return tea.Batch(
-		checkStatusCmd,
-		tickCmd(m.interval),
-	)
+	cmds := []tea.Cmd{checkDriftCmd, checkProcessesCmd, loadHistoryCmd, tickCmd(m.interval)}
+	if m.watcher != nil {
+		cmds = append(cmds, waitForChange(m.watcher))
+	}
+	if m.autoSnap {
+		// Record drift left from before watch started
+		cmds = append(cmds, settleCmd(m.settle, m.autoSeq))
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
+		if m.prompting {
+			return m.updatePrompt(msg)
+		}
+		if m.patchPath != "" {
+			return m.updatePatch(msg)
+		}
+		switch key := msg.String(); key {
 		case "q", "ctrl+c":
 			return m, tea.Quit
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
-		case "down", "j":
-			if m.cursor < len(m.procs)-1 {
-				m.cursor++
+		case "tab":
+			return m.switchPane((m.pane + 1) % numPanes)
+		case "shift+tab":
+			return m.switchPane((m.pane + numPanes - 1) % numPanes)
+		case "1", "2", "3", "4":
+			return m.switchPane(pane(key[0] - '1'))
+		case "s":
+			m.prompting = true
+			m.snapInput = newSnapInput()
+			return m, textinput.Blink
+		}
+		switch m.pane {
+		case driftPane:
+			return m.updateDrift(msg)
+		case historyPane:
+			return m.updateHistory(msg)
+		case processPane:
+			return m.updateProcess(msg)
+		}
+		return m.updateOverview(msg)
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		if m.patchPath != "" {
+			m.patch.Width, m.patch.Height = msg.Width, msg.Height-patchChrome
+		}
+
+	case tickMsg:
+		cmds := []tea.Cmd{checkProcessesCmd, tickCmd(m.interval)}
+		if m.pane == processPane && m.detailPID != 0 {
+			cmds = append(cmds, detailCmd(m.detailPID))
+		}
+		if m.watcher == nil {
+			cmds = append(cmds, checkDriftCmd)
+			if m.autoSnap {
+				cmds = append(cmds, autoSnapCmd)
 			}
-		case "enter", "x", "delete":
-			if len(m.procs) > 0 && m.cursor >= 0 && m.cursor < len(m.procs) {
-				p := m.procs[m.cursor]
-				err := KillPID(p.PID)
-				if err != nil {
-					m.message = fmt.Sprintf("Error killing %s: %v", p.Name, err)
-				} else {
-					m.message = fmt.Sprintf("Killed %s (PID %d)", p.Name, p.PID)
+		}
+		return m, tea.Batch(cmds...)
+
+	case changeMsg:
+		change := watcher.Change(msg)
+		if change.Config() {
+			if root, err := core.FindProjectRoot(); err == nil {
+				if files, err := watchedFiles(root); err == nil {
+					m.watcher.SetFiles(files)
 				}
-				// Clear message after 3 seconds
-				return m, tea.Batch(
-					checkStatusCmd,
-					tickCmd(100*time.Millisecond), // Rapid refresh
-					clearMessageCmd(),
-				)
 			}
 		}
+		cmds := []tea.Cmd{checkDriftCmd, waitForChange(m.watcher)}
+		if change.Refs() || change.Config() {
+			// Port drift is relative to HEAD; services and health checks come from the config
+			cmds = append(cmds, checkProcessesCmd)
+		}
+		if change.Refs() {
+			cmds = append(cmds, loadHistoryCmd)
+		}
+		if m.autoSnap && change.Files() {
+			m.autoSeq++
+			cmds = append(cmds, settleCmd(m.settle, m.autoSeq))
+		}
+		return m, tea.Batch(cmds...)
 
-	case tickMsg:
-		return m, tea.Batch(
-			checkStatusCmd,
-			tickCmd(m.interval),
-		)
+	case settledMsg:
+		if msg.seq == m.autoSeq {
+			return m, autoSnapCmd
+		}
+
+	case autoSnapMsg:
+		switch {
+		case msg.err != nil:
+			m.message = fmt.Sprintf("Error auto-snapping: %v", msg.err)
+		case msg.commit != nil:
+			m.lastAuto = msg.commit
+			m.message = fmt.Sprintf("📸 Auto-snapshot %s (%s)", msg.commit.ShortHash(), msg.commit.Message)
+		default:
+			return m, nil
+		}
+		return m, clearMessageCmd()
+
+	case watchErrMsg:
+		m.message = fmt.Sprintf("Error watching files: %v", msg.err)
+		return m, tea.Batch(waitForChange(m.watcher), clearMessageCmd())
 
-	case statusMsg:
+	case driftMsg:
 		m.envDiff = msg.envDiff
 		m.fileDiff = msg.fileDiff
+		m.policy = msg.policy
+		m.driftErr = msg.err
+		if files := len(m.driftFiles()); m.driftCursor >= files {
+			m.driftCursor = max(files-1, 0)
+		}
+
+	case historyMsg:
+		m.head, m.history, m.historyErr = msg.head, msg.commits, msg.err
+		if m.historyCursor >= len(m.history) {
+			m.historyCursor = max(len(m.history)-1, 0)
+		}
+
+	case patchMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error diffing %s: %v", msg.path, msg.err)
+			return m, clearMessageCmd()
+		}
+		m.openPatch(msg)
+
+	case detailMsg:
+		if msg.pid == m.detailPID {
+			m.setDetail(msg)
+		}
+
+	case snapMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error taking snapshot: %v", msg.err)
+		} else {
+			m.message = fmt.Sprintf("📸 Committed %s (%s)", msg.commit.ShortHash(), msg.commit.Message)
+		}
+		// Without a watcher, refs changes are not noticed
+		return m, tea.Batch(loadHistoryCmd, checkDriftCmd, checkProcessesCmd, clearMessageCmd())
+
+	case restoreMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error restoring %s: %v", msg.commit.ShortHash(), msg.err)
+		} else {
+			m.message = fmt.Sprintf("Restored %d file(s) from %s", len(msg.restored), msg.commit.ShortHash())
+		}
+		return m, tea.Batch(checkDriftCmd, clearMessageCmd())
+
+	case checkoutMsg:
+		switch {
+		case msg.err != nil:
+			m.message = fmt.Sprintf("Error checking out %s: %v", msg.commit.ShortHash(), msg.err)
+		case msg.branch != "":
+			m.message = fmt.Sprintf("Switched to branch '%s'", msg.branch)
+			m.historyCursor = 0
+		default:
+			m.message = fmt.Sprintf("HEAD is now at %s (detached)", msg.commit.ShortHash())
+			m.historyCursor = 0
+		}
+		// Drift and port drift are relative to HEAD
+		return m, tea.Batch(loadHistoryCmd, checkDriftCmd, checkProcessesCmd, clearMessageCmd())
+
+	case actionMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Error %v", msg.err)
+		} else {
+			m.message = msg.done
+			if m.pane == processPane && msg.pid != 0 && msg.pid == m.detailPID {
+				m.pane = overviewPane
+			}
+		}
+		return m, refreshCmd()
+
+	case processMsg:
 		m.procs = msg.procs
-		m.err = msg.err
+		m.liveDiff = msg.liveDiff
+		m.ports = msg.ports
+		m.health = msg.health
+		m.services = msg.services
+		m.procErr = msg.err
 
-		if m.cursor >= len(m.procs) {
-			m.cursor = len(m.procs) - 1
+		if m.cursor >= m.rows() {
+			m.cursor = m.rows() - 1
 		}
 		if m.cursor < 0 {
 			m.cursor = 0
@@ -116,6 +392,54 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.message = ""
 	}
 
+	if m.prompting {
+		var cmd tea.Cmd
+		m.snapInput, cmd = m.snapInput.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// updateOverview handles the keys of the overview: services first, then
+// processes.
+func (m model) updateOverview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < m.rows()-1 {
+			m.cursor++
+		}
+	case "enter":
+		return m.switchPane(processPane)
+	case "x", "delete":
+		if s, ok := m.selectedService(); ok {
+			if !s.Running {
+				break
+			}
+			m.message = fmt.Sprintf("Stopping %s…", s.Name)
+			return m, stopServiceCmd(s)
+		}
+		if p, ok := m.selectedProcess(); ok {
+			return m, killCmd(p.PID, p.Name)
+		}
+	case "r":
+		s, ok := m.selectedService()
+		if !ok {
+			p, isProc := m.selectedProcess()
+			if !isProc {
+				break
+			}
+			if s, ok = serviceOf(m.services, p.PID); !ok {
+				m.message = fmt.Sprintf("Error: %s (PID %d) is not a service", p.Name, p.PID)
+				return m, clearMessageCmd()
+			}
+		}
+		m.message = fmt.Sprintf("Restarting %s…", s.Name)
+		return m, restartServiceCmd(s.Name)
+	}
 	return m, nil
 }
 
@@ -123,14 +447,38 @@ func (m model) View() string {
 	var s strings.Builder
 
 	s.WriteString(titleStyle.Render("👀 Trace Watch"))
-	s.WriteString(" " + dimStyle.Render("(Arrows to nav, Enter/'x' to kill, 'q' to quit)"))
+	s.WriteString(" " + dimStyle.Render("(tab/1-4 switch pane, 's' to snapshot, 'q' to quit)"))
+	s.WriteString("\n")
+	if m.watcher != nil {
+		s.WriteString(dimStyle.Render(fmt.Sprintf("Watching tracked files; processes every %s", m.interval)))
+	} else {
+		s.WriteString(dimStyle.Render(fmt.Sprintf("Polling every %s (file notifications unavailable)", m.interval)))
+	}
+	if m.autoSnap {
+		branch, _ := core.GetCurrentBranch()
+		auto := fmt.Sprintf(" · auto-snap to %s after %s", core.AutoName(branch), m.settle)
+		if m.lastAuto != nil {
+			auto += fmt.Sprintf(" (last: %s)", m.lastAuto.ShortHash())
+		}
+		s.WriteString(dimStyle.Render(auto))
+	}
 	s.WriteString("\n\n")
 
-	if m.err != nil {
-		s.WriteString(warnStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	if m.patchPath != "" {
+		m.renderOpenPatch(&s)
 		return s.String()
 	}
 
+	m.renderTabs(&s)
+	s.WriteString(dimStyle.Render(paneHelp[m.pane]))
+	s.WriteString("\n\n")
+
+	if m.prompting {
+		s.WriteString(m.snapInput.View())
+		s.WriteString("\n" + dimStyle.Render("(enter to commit, esc to cancel)"))
+		s.WriteString("\n\n")
+	}
+
 	// Message Bar
 	if m.message != "" {
 		if strings.HasPrefix(m.message, "Error") {
@@ -141,24 +489,111 @@ func (m model) View() string {
 		s.WriteString("\n\n")
 	}
 
-	clean := m.envDiff.IsEmpty() && m.fileDiff.IsEmpty()
-	if clean {
+	switch m.pane {
+	case driftPane:
+		m.renderDrift(&s)
+		return s.String()
+	case historyPane:
+		m.renderHistory(&s)
+		return s.String()
+	case processPane:
+		m.renderProcess(&s)
+		return s.String()
+	}
+
+	err := m.driftErr
+	if err == nil {
+		err = m.procErr
+	}
+	if err != nil {
+		s.WriteString(warnStyle.Render(fmt.Sprintf("Error: %v", err)))
+		return s.String()
+	}
+
+	env := withLiveEnv(m.envDiff, m.liveDiff)
+	if env.IsEmpty() && m.fileDiff.IsEmpty() {
 		s.WriteString(successStyle.Render("✨ Environment Clean"))
 	} else {
 		s.WriteString(warnStyle.Render("⚠️  Changes Not Committed:"))
 		s.WriteString("\n")
-		renderDiffs(&s, m.fileDiff, m.envDiff)
+		renderDiffs(&s, m.fileDiff, env)
 	}
 
 	s.WriteString("\n\n")
 
+	if len(m.policy) > 0 {
+		s.WriteString(warnStyle.Render(fmt.Sprintf("🚫 Policy Violations (%d):", len(m.policy))))
+		s.WriteString("\n")
+		for _, v := range m.policy {
+			s.WriteString(fmt.Sprintf("  %s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Render("✗"), v))
+		}
+		s.WriteString("\n")
+	}
+
+	if !m.ports.IsEmpty() {
+		s.WriteString(warnStyle.Render("🔌 Ports:"))
+		s.WriteString("\n")
+		for _, issue := range m.ports.Issues {
+			s.WriteString(fmt.Sprintf("  %s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Render("✗"), issue))
+		}
+		for _, p := range m.ports.Diff.Opened {
+			s.WriteString(fmt.Sprintf("  %s %s opened\n", lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render("+"), p))
+		}
+		for _, p := range m.ports.Diff.Closed {
+			s.WriteString(fmt.Sprintf("  %s %s closed\n", lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Render("-"), p))
+		}
+		for _, p := range m.ports.Diff.Changed {
+			s.WriteString(fmt.Sprintf("  %s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("~"), p))
+		}
+		s.WriteString("\n")
+	}
+
+	if len(m.health) > 0 {
+		failed := unhealthy(m.health)
+		title := fmt.Sprintf("🩺 Health (%d/%d passing):", len(m.health)-len(failed), len(m.health))
+		if len(failed) > 0 {
+			s.WriteString(warnStyle.Render(title))
+		} else {
+			s.WriteString(successStyle.Render(title))
+		}
+		s.WriteString("\n")
+		for _, r := range m.health {
+			if r.Healthy {
+				s.WriteString(fmt.Sprintf("  %s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render("✓"), r.Name))
+				continue
+			}
+			s.WriteString(fmt.Sprintf("  %s %s %s\n", lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Render("✗"), r.Name, dimStyle.Render(r.Detail)))
+		}
+		s.WriteString("\n")
+	}
+
+	if len(m.services) > 0 {
+		s.WriteString(subTitleStyle.Render(fmt.Sprintf("⚙️  Services (%d)", len(m.services))))
+		s.WriteString("\n")
+		for i, svc := range m.services {
+			cursor := "  "
+			style := dimStyle
+			if i == m.cursor {
+				cursor = "> "
+				style = selectedStyle
+			}
+
+			state := lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Render("○ stopped")
+			if svc.Running {
+				state = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render(fmt.Sprintf("● [%d]", svc.PID))
+			}
+			s.WriteString(fmt.Sprintf("%s %s\n", style.Render(fmt.Sprintf("%s• %s", cursor, svc.Name)), state))
+		}
+		s.WriteString("\n")
+	}
+
 	if len(m.procs) > 0 {
 		s.WriteString(subTitleStyle.Render(fmt.Sprintf("🚀 Active Processes (%d)", len(m.procs))))
 		s.WriteString("\n")
 		for i, p := range m.procs {
 			cursor := "  "
 			style := dimStyle
-			if i == m.cursor {
+			if len(m.services)+i == m.cursor {
 				cursor = "> "
 				style = selectedStyle
 			}
@@ -182,6 +617,92 @@ func (m model) View() string {
 	return s.String()
 }
 
+// rows returns the number of selectable rows: services, then processes.
+func (m model) rows() int {
+	return len(m.services) + len(m.procs)
+}
+
+func (m model) selectedService() (services.Service, bool) {
+	if m.cursor >= 0 && m.cursor < len(m.services) {
+		return m.services[m.cursor], true
+	}
+	return services.Service{}, false
+}
+
+func (m model) selectedProcess() (monitor.ProcessInfo, bool) {
+	i := m.cursor - len(m.services)
+	if i >= 0 && i < len(m.procs) {
+		return m.procs[i], true
+	}
+	return monitor.ProcessInfo{}, false
+}
+
+// refreshCmd refreshes the processes right after an action and clears the
+// message after 3 seconds.
+func refreshCmd() tea.Cmd {
+	return tea.Batch(
+		checkProcessesCmd,
+		clearMessageCmd(),
+	)
+}
+
+// stopServiceCmd stops a service in the background, as it may take until
+// the stop timeout.
+func stopServiceCmd(s services.Service) tea.Cmd {
+	return func() tea.Msg {
+		if err := stopService(s.Name); err != nil {
+			return actionMsg{err: fmt.Errorf("stopping %s: %w", s.Name, err)}
+		}
+		return actionMsg{done: fmt.Sprintf("Stopped %s (PID %d)", s.Name, s.PID)}
+	}
+}
+
+func restartServiceCmd(name string) tea.Cmd {
+	return func() tea.Msg {
+		pid, err := restartService(name)
+		if err != nil {
+			return actionMsg{err: fmt.Errorf("restarting %s: %w", name, err)}
+		}
+		return actionMsg{done: fmt.Sprintf("Restarted %s (PID %d)", name, pid)}
+	}
+}
+
+func killCmd(pid int32, name string) tea.Cmd {
+	return func() tea.Msg {
+		if err := KillPID(pid); err != nil {
+			return actionMsg{err: fmt.Errorf("killing %s: %w", name, err)}
+		}
+		return actionMsg{done: fmt.Sprintf("Killed %s (PID %d)", name, pid), pid: pid}
+	}
+}
+
+// settleCmd reports when the settle time after file change seq has passed.
+func settleCmd(d time.Duration, seq int) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return settledMsg{seq}
+	})
+}
+
+func autoSnapCmd() tea.Msg {
+	commit, err := AutoSnap()
+	return autoSnapMsg{commit: commit, err: err}
+}
+
+// waitForChange delivers the next change reported by the watcher.
+func waitForChange(w *watcher.Watcher) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case change, ok := <-w.Changes:
+			if !ok {
+				return nil
+			}
+			return changeMsg(change)
+		case err := <-w.Errors:
+			return watchErrMsg{err}
+		}
+	}
+}
+
 func tickCmd(d time.Duration) tea.Cmd {
 	return tea.Tick(d, func(t time.Time) tea.Msg {
 		return tickMsg(t)
@@ -194,12 +715,39 @@ func clearMessageCmd() tea.Cmd {
 	})
 }
 
-func checkStatusCmd() tea.Msg {
-	envDiff, fileDiff, procs, err := GetStatus()
-	return statusMsg{
+func checkDriftCmd() tea.Msg {
+	envDiff, fileDiff, err := getDrift()
+	violations, policyErr := checkPolicy()
+	if err == nil {
+		err = policyErr
+	}
+	return driftMsg{
 		envDiff:  envDiff,
 		fileDiff: fileDiff,
+		policy:   violations,
+		err:      err,
+	}
+}
+
+func checkProcessesCmd() tea.Msg {
+	cwd, _ := os.Getwd()
+	// Processes that cannot be listed are left out, as in status
+	procs, _ := monitor.GetProjectProcesses(cwd)
+	liveDiff, err := liveEnvDrift(procs)
+	portStatus, portErr := checkPortStatus(procs)
+	health, healthErr := checkHealth()
+	if err == nil {
+		err = portErr
+	}
+	if err == nil {
+		err = healthErr
+	}
+	return processMsg{
 		procs:    procs,
+		liveDiff: liveDiff,
+		ports:    portStatus,
+		health:   health,
+		services: serviceStates(),
 		err:      err,
 	}
 }
@@ -216,12 +764,12 @@ func renderDiffs(s *strings.Builder, files diff.FileDiff, env diff.EnvDiff) {
 	}
 
 	for _, k := range env.Added {
-		s.WriteString(fmt.Sprintf("  %s %s (env)\n", lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render("+"), k))
+		s.WriteString(fmt.Sprintf("  %s %s (env)\n", lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render("+"), env.Label(k)))
 	}
 	for _, k := range env.Removed {
-		s.WriteString(fmt.Sprintf("  %s %s (env)\n", lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Render("-"), k))
+		s.WriteString(fmt.Sprintf("  %s %s (env)\n", lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Render("-"), env.Label(k)))
 	}
 	for _, k := range env.Changed {
-		s.WriteString(fmt.Sprintf("  %s %s (env)\n", lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("~"), k))
+		s.WriteString(fmt.Sprintf("  %s %s (env)\n", lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("~"), env.Label(k)))
 	}
 }
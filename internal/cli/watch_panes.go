@@ -0,0 +1,546 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"trace/internal/core"
+	"trace/internal/diff"
+	"trace/internal/monitor"
+	"trace/internal/store"
+)
+
+// pane is a tab of the watch TUI.
+type pane int
+
+const (
+	overviewPane pane = iota // Drift summary, services and processes
+	driftPane                // Changed files, with their content diff against HEAD
+	historyPane              // Commits from HEAD, to restore or check out
+	processPane              // Details of the selected process
+	numPanes
+)
+
+var paneNames = [numPanes]string{"Overview", "Drift", "History", "Process"}
+
+// paneHelp lists the keys of each pane.
+var paneHelp = [numPanes]string{
+	"↑/↓ select · enter details · x stop/kill · r restart",
+	"↑/↓ select · enter diff against HEAD",
+	"↑/↓ select · r restore files · c check out",
+	"x kill · esc back",
+}
+
+// historyMsg carries the commits reachable from HEAD.
+type historyMsg struct {
+	head    string
+	commits []*core.Commit
+	err     error
+}
+
+// patchMsg carries the content diff of a tracked file against HEAD.
+type patchMsg struct {
+	path    string
+	content string
+	err     error
+}
+
+// detailMsg carries the details of the process shown in the process pane.
+type detailMsg struct {
+	pid   int32
+	stats monitor.ProcessStats
+	at    time.Time
+	err   error
+}
+
+// snapMsg reports a snapshot taken from the prompt.
+type snapMsg struct {
+	commit *core.Commit
+	err    error
+}
+
+// restoreMsg reports the files restored from a commit of the history pane.
+type restoreMsg struct {
+	commit   *core.Commit
+	restored []string
+	err      error
+}
+
+// checkoutMsg reports a checkout from the history pane.
+type checkoutMsg struct {
+	commit *core.Commit
+	branch string // Empty if HEAD was detached
+	err    error
+}
+
+// cpuSample is the CPU time of a process at some point, to compute its
+// current CPU usage from the next sample.
+type cpuSample struct {
+	pid int32
+	cpu time.Duration
+	at  time.Time
+}
+
+// switchPane shows pane p, loading what it needs.
+func (m model) switchPane(p pane) (model, tea.Cmd) {
+	m.pane = p
+	switch p {
+	case historyPane:
+		return m, loadHistoryCmd
+	case processPane:
+		pid := int32(0)
+		if s, ok := m.selectedService(); ok && s.Running {
+			pid = s.PID
+		} else if proc, ok := m.selectedProcess(); ok {
+			pid = proc.PID
+		}
+		if pid == 0 {
+			return m, nil
+		}
+		if pid != m.detailPID {
+			m.detailPID = pid
+			m.detail = nil
+			m.detailErr = nil
+		}
+		return m, detailCmd(pid)
+	}
+	return m, nil
+}
+
+// updatePrompt handles keys while the snapshot message is being typed.
+func (m model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.prompting = false
+		return m, nil
+	case "enter":
+		message := strings.TrimSpace(m.snapInput.Value())
+		if message == "" {
+			m.message = "Error: commit message required"
+			return m, clearMessageCmd()
+		}
+		m.prompting = false
+		return m, snapCmd(message)
+	}
+	var cmd tea.Cmd
+	m.snapInput, cmd = m.snapInput.Update(msg)
+	return m, cmd
+}
+
+// updatePatch handles keys while a content diff is open.
+func (m model) updatePatch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "q", "esc":
+		m.patchPath = ""
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.patch, cmd = m.patch.Update(msg)
+	return m, cmd
+}
+
+func (m model) updateDrift(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	files := m.driftFiles()
+	switch msg.String() {
+	case "up", "k":
+		if m.driftCursor > 0 {
+			m.driftCursor--
+		}
+	case "down", "j":
+		if m.driftCursor < len(files)-1 {
+			m.driftCursor++
+		}
+	case "enter":
+		if m.driftCursor < len(files) {
+			return m, loadPatchCmd(files[m.driftCursor])
+		}
+	}
+	return m, nil
+}
+
+func (m model) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.historyCursor > 0 {
+			m.historyCursor--
+		}
+	case "down", "j":
+		if m.historyCursor < len(m.history)-1 {
+			m.historyCursor++
+		}
+	case "r":
+		if m.historyCursor >= len(m.history) {
+			break
+		}
+		return m, restoreCmd(m.history[m.historyCursor])
+	case "c":
+		if m.historyCursor >= len(m.history) {
+			break
+		}
+		return m, checkoutCmd(m.history[m.historyCursor])
+	}
+	return m, nil
+}
+
+func (m model) updateProcess(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.pane = overviewPane
+	case "x", "delete":
+		if m.detailPID == 0 {
+			break
+		}
+		return m, killCmd(m.detailPID, monitor.GetProcessName(m.detailPID))
+	}
+	return m, nil
+}
+
+// driftFiles returns the changed files in the order the drift pane lists them.
+func (m model) driftFiles() []string {
+	var files []string
+	files = append(files, m.fileDiff.Modified...)
+	files = append(files, m.fileDiff.Added...)
+	files = append(files, m.fileDiff.Removed...)
+	return files
+}
+
+// setDetail records new process details and derives the CPU usage since
+// the previous sample, or since the process started.
+func (m *model) setDetail(msg detailMsg) {
+	m.detailErr = msg.err
+	if msg.err != nil {
+		m.detail = nil
+		return
+	}
+	stats := msg.stats
+	m.detail = &stats
+
+	m.cpuLive = false
+	if prev := m.cpuSample; prev.pid == stats.PID && msg.at.After(prev.at) {
+		m.cpuPercent = 100 * float64(stats.CPUTime-prev.cpu) / float64(msg.at.Sub(prev.at))
+		m.cpuLive = true
+	} else if elapsed := msg.at.Sub(stats.Started); !stats.Started.IsZero() && elapsed > 0 {
+		m.cpuPercent = 100 * float64(stats.CPUTime) / float64(elapsed)
+	}
+	m.cpuSample = cpuSample{pid: stats.PID, cpu: stats.CPUTime, at: msg.at}
+}
+
+// openPatch shows a content diff in a scrollable viewport.
+func (m *model) openPatch(msg patchMsg) {
+	width, height := m.width, m.height-patchChrome
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 20
+	}
+	m.patch = viewport.New(width, height)
+	m.patch.SetContent(msg.content)
+	m.patchPath = msg.path
+}
+
+// patchChrome is the number of lines around the diff viewport: the watch
+// header, the title of the diff and the scroll position.
+const patchChrome = 6
+
+// newSnapInput returns the prompt for a snapshot message.
+func newSnapInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "📸 Snapshot message: "
+	input.Placeholder = "describe the environment"
+	input.CharLimit = 200
+	input.Focus()
+	return input
+}
+
+func (m model) renderTabs(s *strings.Builder) {
+	for i, name := range paneNames {
+		label := fmt.Sprintf(" %d %s ", i+1, name)
+		if pane(i) == m.pane {
+			s.WriteString(lipgloss.NewStyle().Bold(true).Reverse(true).Foreground(lipgloss.Color("205")).Render(label))
+		} else {
+			s.WriteString(dimStyle.Render(label))
+		}
+	}
+	s.WriteString("\n")
+}
+
+func (m model) renderOpenPatch(s *strings.Builder) {
+	s.WriteString(subTitleStyle.Render(fmt.Sprintf("📄 %s against HEAD", m.patchPath)))
+	s.WriteString(" " + dimStyle.Render("(scroll with j/k/arrows, esc to close)"))
+	s.WriteString("\n\n")
+	s.WriteString(m.patch.View())
+	s.WriteString("\n")
+	s.WriteString(dimStyle.Render(fmt.Sprintf("%3.f%%", m.patch.ScrollPercent()*100)))
+}
+
+func (m model) renderDrift(s *strings.Builder) {
+	if m.driftErr != nil {
+		s.WriteString(warnStyle.Render(fmt.Sprintf("Error: %v", m.driftErr)))
+		return
+	}
+
+	files := m.driftFiles()
+	env := withLiveEnv(m.envDiff, m.liveDiff)
+	if len(files) == 0 && env.IsEmpty() {
+		s.WriteString(successStyle.Render("✨ Environment Clean"))
+		return
+	}
+
+	if len(files) > 0 {
+		s.WriteString(subTitleStyle.Render(fmt.Sprintf("📄 Files (%d)", len(files))))
+		s.WriteString("\n")
+		for i, path := range files {
+			mark := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("~")
+			if i >= len(m.fileDiff.Modified)+len(m.fileDiff.Added) {
+				mark = lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Render("-")
+			} else if i >= len(m.fileDiff.Modified) {
+				mark = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render("+")
+			}
+			cursor, style := "  ", dimStyle
+			if i == m.driftCursor {
+				cursor, style = "> ", selectedStyle
+			}
+			s.WriteString(fmt.Sprintf("%s%s %s\n", cursor, mark, style.Render(path)))
+		}
+		s.WriteString("\n")
+	}
+
+	if !env.IsEmpty() {
+		n := len(env.Added) + len(env.Removed) + len(env.Changed)
+		s.WriteString(subTitleStyle.Render(fmt.Sprintf("🔑 Env keys (%d)", n)))
+		s.WriteString("\n")
+		renderDiffs(s, diff.FileDiff{}, env)
+	}
+}
+
+func (m model) renderHistory(s *strings.Builder) {
+	if m.historyErr != nil {
+		s.WriteString(warnStyle.Render(fmt.Sprintf("Error: %v", m.historyErr)))
+		return
+	}
+	if len(m.history) == 0 {
+		s.WriteString(dimStyle.Render("No commits yet. Press 's' to take the first snapshot."))
+		return
+	}
+
+	branch, _ := core.GetCurrentBranch()
+	start, end := visibleRange(m.historyCursor, len(m.history), m.height-8)
+	for i := start; i < end; i++ {
+		c := m.history[i]
+		cursor, style := "  ", dimStyle
+		if i == m.historyCursor {
+			cursor, style = "> ", selectedStyle
+		}
+		date := c.Timestamp
+		if t, err := time.Parse(time.RFC3339, c.Timestamp); err == nil {
+			date = t.Local().Format("2006-01-02 15:04")
+		}
+		ref := ""
+		if c.Hash == m.head {
+			ref = " (HEAD"
+			if branch != "" {
+				ref += " -> " + branch
+			}
+			ref += ")"
+		}
+		s.WriteString(fmt.Sprintf("%s%s %s %s%s\n", cursor,
+			lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render(c.ShortHash()),
+			dimStyle.Render(date), style.Render(c.Message),
+			lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Render(ref)))
+	}
+	if end-start < len(m.history) {
+		s.WriteString(dimStyle.Render(fmt.Sprintf("\n%d/%d commits", m.historyCursor+1, len(m.history))))
+	}
+}
+
+func (m model) renderProcess(s *strings.Builder) {
+	if m.detailPID == 0 {
+		s.WriteString(dimStyle.Render("Select a process or service in the overview and press Enter."))
+		return
+	}
+	if m.detailErr != nil {
+		s.WriteString(warnStyle.Render(fmt.Sprintf("Error: %v", m.detailErr)))
+		return
+	}
+	if m.detail == nil {
+		s.WriteString(dimStyle.Render("Loading..."))
+		return
+	}
+
+	d := m.detail
+	s.WriteString(subTitleStyle.Render(fmt.Sprintf("🔍 [%d] %s", d.PID, d.Name)))
+	if svc, ok := serviceOf(m.services, d.PID); ok {
+		s.WriteString(dimStyle.Render(fmt.Sprintf(" (service %s)", svc.Name)))
+	}
+	s.WriteString("\n\n")
+
+	field := func(label, value string) {
+		s.WriteString(fmt.Sprintf("  %s %s\n", dimStyle.Render(fmt.Sprintf("%-9s", label)), value))
+	}
+	field("Command", d.Cmdline)
+	if d.Cwd != "" {
+		field("Cwd", d.Cwd)
+	}
+	if d.Container != "" {
+		field("Container", d.Container)
+	}
+	cpu := fmt.Sprintf("%.1f%%", m.cpuPercent)
+	if !m.cpuLive {
+		cpu += dimStyle.Render(" (average since start)")
+	}
+	field("CPU", cpu)
+	field("RSS", formatBytes(int64(d.RSS)))
+	if !d.Started.IsZero() {
+		field("Started", fmt.Sprintf("%s (%s ago)", d.Started.Format("2006-01-02 15:04:05"), time.Since(d.Started).Round(time.Second)))
+	}
+	ports := "none"
+	if len(d.Ports) > 0 {
+		strPorts := make([]string, len(d.Ports))
+		for i, port := range d.Ports {
+			strPorts[i] = fmt.Sprintf("%d", port)
+		}
+		ports = strings.Join(strPorts, ", ")
+	}
+	field("Ports", ports)
+
+	s.WriteString("\n")
+	s.WriteString(subTitleStyle.Render(fmt.Sprintf("👶 Children (%d)", len(d.Children))))
+	s.WriteString("\n")
+	for _, c := range d.Children {
+		line := fmt.Sprintf("  • [%d] %s", c.PID, c.Name)
+		if len(c.Ports) > 0 {
+			strPorts := make([]string, len(c.Ports))
+			for i, port := range c.Ports {
+				strPorts[i] = fmt.Sprintf("%d", port)
+			}
+			line += dimStyle.Render(fmt.Sprintf(" :%s", strings.Join(strPorts, ",")))
+		}
+		s.WriteString(line + "\n")
+	}
+}
+
+// visibleRange returns the slice of n rows to show around cursor when only
+// rows fit; at least 10 rows are shown.
+func visibleRange(cursor, n, rows int) (int, int) {
+	if rows < 10 {
+		rows = 10
+	}
+	if n <= rows {
+		return 0, n
+	}
+	start := cursor - rows/2
+	if start < 0 {
+		start = 0
+	}
+	if start+rows > n {
+		start = n - rows
+	}
+	return start, start + rows
+}
+
+func loadHistoryCmd() tea.Msg {
+	head, err := core.GetHEAD()
+	if err != nil || head == "" {
+		return historyMsg{err: err}
+	}
+	commits, err := store.GetCommitHistory(head)
+	return historyMsg{head: head, commits: commits, err: err}
+}
+
+func loadPatchCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		content, err := filePatch(path)
+		return patchMsg{path: path, content: content, err: err}
+	}
+}
+
+// filePatch renders the changes of a tracked file since HEAD.
+func filePatch(path string) (string, error) {
+	head, err := core.GetHEAD()
+	if err != nil {
+		return "", fmt.Errorf("get HEAD: %w", err)
+	}
+	var old []byte
+	if head != "" {
+		commit, err := store.LoadCommit(head)
+		if err != nil {
+			return "", fmt.Errorf("load HEAD: %w", err)
+		}
+		if hash, ok := commit.Snapshot.Files[path]; ok {
+			if old, err = store.LoadBlob(hash); err != nil {
+				return "", fmt.Errorf("load %s: %w", path, err)
+			}
+		}
+	}
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	var sb strings.Builder
+	renderPatch(&sb, path, old, current, diff.DefaultContext, false)
+	return sb.String(), nil
+}
+
+func detailCmd(pid int32) tea.Cmd {
+	return func() tea.Msg {
+		stats, err := monitor.GetProcessStats(pid)
+		return detailMsg{pid: pid, stats: stats, at: time.Now(), err: err}
+	}
+}
+
+func restoreCmd(c *core.Commit) tea.Cmd {
+	return func() tea.Msg {
+		restored, err := restoreCommit(c.Hash)
+		return restoreMsg{commit: c, restored: restored, err: err}
+	}
+}
+
+// checkoutCmd checks out a commit by the name of a branch whose tip it is,
+// so that HEAD stays on a branch, and detaches HEAD otherwise.
+func checkoutCmd(c *core.Commit) tea.Cmd {
+	return func() tea.Msg {
+		ref := c.Hash
+		if branch := tipOf(c.Hash); branch != "" {
+			ref = branch
+		}
+		branch, _, err := checkoutRef(ref)
+		return checkoutMsg{commit: c, branch: branch, err: err}
+	}
+}
+
+// tipOf returns a branch pointing at hash, preferring the current branch,
+// or "" if there is none.
+func tipOf(hash string) string {
+	if current, _ := core.GetCurrentBranch(); current != "" {
+		if tip, _ := core.GetBranch(current); tip == hash {
+			return current
+		}
+	}
+	branches, _ := core.ListBranches()
+	for _, branch := range branches {
+		if tip, _ := core.GetBranch(branch); tip == hash {
+			return branch
+		}
+	}
+	return ""
+}
+
+func snapCmd(message string) tea.Cmd {
+	return func() tea.Msg {
+		commit, err := CreateSnapshot(message)
+		return snapMsg{commit: commit, err: err}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"trace/internal/core"
 )
 
@@ -12,10 +13,17 @@ const TraceDir = ".trace"
 
 // Config defines the trace configuration.
 type Config struct {
-	TrackedFiles    []string `json:"tracked_files"`
-	DefaultBranch   string   `json:"default_branch,omitempty"`
-	BackupOnRestore bool     `json:"backup_on_restore,omitempty"`
-	Hooks           Hooks    `json:"hooks,omitempty"`
+	TrackedFiles    []string          `json:"tracked_files"`
+	DefaultBranch   string            `json:"default_branch,omitempty"`
+	BackupOnRestore bool              `json:"backup_on_restore,omitempty"`
+	Hooks           Hooks             `json:"hooks,omitempty"`
+	Encryption      Encryption        `json:"encryption,omitempty"`
+	LiveEnv         LiveEnv           `json:"live_env,omitempty"`
+	ExpectedPorts   []ExpectedPort    `json:"expected_ports,omitempty"`
+	HealthChecks    []HealthCheck     `json:"health_checks,omitempty"`
+	Services        map[string]string `json:"services,omitempty"` // Name -> shell command, like a Procfile
+	AutoSnap        AutoSnap          `json:"auto_snap,omitempty"`
+	EnvTemplate     EnvTemplate       `json:"env_template,omitempty"`
 }
 
 // Hooks defines commands to run around lifecycle events.
@@ -24,6 +32,66 @@ type Hooks struct {
 	PostRestore string `json:"post_restore,omitempty"`
 }
 
+// Encryption configures at-rest encryption of stored file contents.
+type Encryption struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	KeyFile string `json:"key_file,omitempty"` // Defaults to .trace/key; TRACE_KEY overrides
+}
+
+// LiveEnv selects environment variables recorded from outside the .env files.
+// Both lists hold glob patterns of key names; nothing is recorded by default.
+type LiveEnv struct {
+	Shell     []string `json:"shell,omitempty"`     // Keys of the environment trace runs in
+	Processes []string `json:"processes,omitempty"` // Keys of the environments of project processes
+}
+
+// Enabled reports whether any live environment keys are collected.
+func (l LiveEnv) Enabled() bool {
+	return len(l.Shell) > 0 || len(l.Processes) > 0
+}
+
+// AutoSnap configures the snapshots watch takes by itself when tracked
+// files change. They are recorded on auto/<branch>, not on the branch.
+type AutoSnap struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Settle  string `json:"settle,omitempty"` // Quiet time after a change before snapping, e.g. "10s" (default: 3s)
+}
+
+// EnvTemplate configures `trace env template`.
+type EnvTemplate struct {
+	// Keep lists glob patterns of keys whose values are copied to the
+	// template as they are; other values are only kept if they are
+	// obviously harmless (booleans, small numbers, localhost URLs).
+	Keep []string `json:"keep,omitempty"`
+}
+
+// ExpectedPort is a port the project's processes should be listening on.
+type ExpectedPort struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol,omitempty"` // "tcp" (default) or "udp"
+	Process  string `json:"process,omitempty"`  // Name of the process that should own the port
+}
+
+// Proto returns the protocol of the port, defaulting to tcp.
+func (p ExpectedPort) Proto() string {
+	if p.Protocol == "" {
+		return "tcp"
+	}
+	return strings.ToLower(p.Protocol)
+}
+
+// HealthCheck probes whether a project service works. Exactly one of TCP,
+// HTTP and Command is set.
+type HealthCheck struct {
+	Name    string `json:"name"`
+	TCP     string `json:"tcp,omitempty"`     // host:port that must accept connections
+	HTTP    string `json:"http,omitempty"`    // URL that must answer a GET
+	Status  int    `json:"status,omitempty"`  // Expected HTTP status (default: any 2xx)
+	Body    string `json:"body,omitempty"`    // Regular expression the HTTP body must match
+	Command string `json:"command,omitempty"` // Shell command that must exit 0
+	Timeout string `json:"timeout,omitempty"` // e.g. "2s" (default: 5s)
+}
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() Config {
 	return Config{
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -19,8 +20,23 @@ type Commit struct {
 
 // Snapshot holds the environment state at commit time.
 type Snapshot struct {
-	EnvKeys map[string]string `json:"env_keys"`  // key -> hash of value
-	Files   map[string]string `json:"files"`     // path -> content hash
+	EnvKeys  map[string]string            `json:"env_keys"`            // key -> hash of effective value
+	Files    map[string]string            `json:"files"`               // path -> content hash
+	EnvFiles map[string]map[string]string `json:"env_files,omitempty"` // env file -> key -> hash of value
+	LiveEnv  map[string]map[string]string `json:"live_env,omitempty"`  // "shell" or "process:<name>" -> key -> hash of value
+	Ports    []PortBinding                `json:"ports,omitempty"`     // Ports project processes listened on, sorted
+}
+
+// PortBinding is a port a project process was listening on.
+type PortBinding struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Process  string `json:"process,omitempty"`
+}
+
+// String formats the binding as "8080/tcp".
+func (b PortBinding) String() string {
+	return fmt.Sprintf("%d/%s", b.Port, b.Protocol)
 }
 
 // NewCommit creates a new commit with the given parent, message, and snapshot.
@@ -53,6 +69,11 @@ func (c *Commit) computeHash() string {
 	return hex.EncodeToString(h[:])
 }
 
+// VerifyHash reports whether the stored hash matches the commit content.
+func (c *Commit) VerifyHash() bool {
+	return c.Hash == c.computeHash()
+}
+
 // ShortHash returns the first 7 characters of the commit hash.
 func (c *Commit) ShortHash() string {
 	if len(c.Hash) >= 7 {
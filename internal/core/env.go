@@ -0,0 +1,97 @@
+package core
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// envTemplateSuffixes mark env files that are templates, not real environments.
+var envTemplateSuffixes = []string{".example", ".sample", ".template", ".dist"}
+
+// IsEnvFile reports whether a tracked file holds env keys (.env, .env.local, ...).
+func IsEnvFile(path string) bool {
+	return strings.HasSuffix(path, ".env") || strings.Contains(path, ".env.")
+}
+
+// IsEnvTemplate reports whether an env file is a template such as .env.example.
+func IsEnvTemplate(path string) bool {
+	for _, suffix := range envTemplateSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvPrecedence ranks an env file the way dotenv loaders do; files with a
+// higher rank override keys of files with a lower one:
+//
+//	.env.example < .env < .env.local < .env.<mode> < .env.<mode>.local
+func EnvPrecedence(path string) int {
+	base := filepath.Base(path)
+	switch {
+	case IsEnvTemplate(base):
+		return 0
+	case base == ".env" || !strings.Contains(base, ".env."):
+		return 1
+	case base == ".env.local":
+		return 2
+	case strings.HasSuffix(base, ".local"):
+		return 4
+	default:
+		return 3
+	}
+}
+
+// SortByEnvPrecedence orders paths from lowest to highest env precedence,
+// keeping the given order among files of the same rank.
+func SortByEnvPrecedence(paths []string) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		return EnvPrecedence(paths[i]) < EnvPrecedence(paths[j])
+	})
+}
+
+// EnvSources returns the env files of the snapshot that define key, the one
+// whose value is effective first.
+func (s *Snapshot) EnvSources(key string) []string {
+	var files []string
+	for path, keys := range s.EnvFiles {
+		if _, ok := keys[key]; ok {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	SortByEnvPrecedence(files)
+
+	// Reverse to highest precedence first
+	for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+		files[i], files[j] = files[j], files[i]
+	}
+	return files
+}
+
+// Sources of live environment keys in Snapshot.LiveEnv.
+const (
+	ShellEnvSource   = "shell"
+	ProcessEnvPrefix = "process:"
+)
+
+// AllEnvKeys returns the effective .env keys together with the live
+// environment keys, which are named "<source>/<KEY>" (e.g. shell/NODE_ENV or
+// process:node/PORT) so they never collide with .env keys.
+func (s *Snapshot) AllEnvKeys() map[string]string {
+	if len(s.LiveEnv) == 0 {
+		return s.EnvKeys
+	}
+	keys := make(map[string]string, len(s.EnvKeys))
+	for k, v := range s.EnvKeys {
+		keys[k] = v
+	}
+	for source, live := range s.LiveEnv {
+		for k, v := range live {
+			keys[source+"/"+k] = v
+		}
+	}
+	return keys
+}
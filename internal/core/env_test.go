@@ -0,0 +1,54 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortByEnvPrecedence(t *testing.T) {
+	paths := []string{".env.development.local", "docker-compose.yml", ".env.local", ".env.development", ".env", ".env.example"}
+	SortByEnvPrecedence(paths)
+
+	want := []string{".env.example", "docker-compose.yml", ".env", ".env.local", ".env.development", ".env.development.local"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("SortByEnvPrecedence() = %v, want %v", paths, want)
+	}
+}
+
+func TestEnvSources(t *testing.T) {
+	s := Snapshot{
+		EnvFiles: map[string]map[string]string{
+			".env":             {"PORT": "a", "HOST": "b"},
+			".env.local":       {"PORT": "c"},
+			".env.development": {"PORT": "d"},
+		},
+	}
+
+	if got := s.EnvSources("PORT"); !reflect.DeepEqual(got, []string{".env.development", ".env.local", ".env"}) {
+		t.Errorf("EnvSources(PORT) = %v", got)
+	}
+	if got := s.EnvSources("HOST"); !reflect.DeepEqual(got, []string{".env"}) {
+		t.Errorf("EnvSources(HOST) = %v", got)
+	}
+	if got := s.EnvSources("MISSING"); len(got) != 0 {
+		t.Errorf("EnvSources(MISSING) = %v", got)
+	}
+}
+
+func TestAllEnvKeys(t *testing.T) {
+	s := Snapshot{
+		EnvKeys: map[string]string{"PORT": "a"},
+		LiveEnv: map[string]map[string]string{
+			ShellEnvSource:            {"PORT": "b"},
+			ProcessEnvPrefix + "node": {"NODE_ENV": "c"},
+		},
+	}
+
+	want := map[string]string{"PORT": "a", "shell/PORT": "b", "process:node/NODE_ENV": "c"}
+	if got := s.AllEnvKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllEnvKeys() = %v, want %v", got, want)
+	}
+	if len(s.EnvKeys) != 1 {
+		t.Errorf("AllEnvKeys modified EnvKeys: %v", s.EnvKeys)
+	}
+}
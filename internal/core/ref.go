@@ -12,6 +12,7 @@ const (
 	HeadFile   = ".trace/HEAD"
 	RefsDir    = ".trace/refs"
 	HeadsDir   = ".trace/refs/heads"
+	AutoDir    = ".trace/refs/auto"
 	DefaultRef = "main"
 )
 
@@ -40,7 +41,8 @@ func GetHEAD() (string, error) {
 
 // SetHEAD updates HEAD to point to the given commit hash.
 // If HEAD currently points to a branch, it updates the branch instead.
-func SetHEAD(hash string) error {
+// The move is recorded in the reflog of HEAD (and the branch) with action and message.
+func SetHEAD(hash, action, message string) error {
 	data, err := os.ReadFile(HeadFile)
 	if err != nil && !os.IsNotExist(err) {
 		return err
@@ -51,11 +53,36 @@ func SetHEAD(hash string) error {
 	// If HEAD points to a ref, update that ref
 	if strings.HasPrefix(content, "ref: ") {
 		refPath := strings.TrimPrefix(content, "ref: ")
-		return writeRef(filepath.Join(TraceDir, refPath), hash)
+		old, err := readRef(filepath.Join(TraceDir, refPath))
+		if err != nil {
+			return err
+		}
+		if err := writeRef(filepath.Join(TraceDir, refPath), hash); err != nil {
+			return err
+		}
+		if err := appendReflog(refPath, old, hash, action, message); err != nil {
+			return err
+		}
+		return appendReflog(HeadRef, old, hash, action, message)
 	}
 
 	// Otherwise, update HEAD directly
-	return os.WriteFile(HeadFile, []byte(hash+"\n"), 0644)
+	if err := os.WriteFile(HeadFile, []byte(hash+"\n"), 0644); err != nil {
+		return err
+	}
+	return appendReflog(HeadRef, content, hash, action, message)
+}
+
+// DetachHEAD points HEAD directly at a commit, leaving any branch untouched.
+func DetachHEAD(hash, action, message string) error {
+	old, err := GetHEAD()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(HeadFile, []byte(hash+"\n"), 0644); err != nil {
+		return err
+	}
+	return appendReflog(HeadRef, old, hash, action, message)
 }
 
 // GetCurrentBranch returns the name of the current branch, or empty if detached.
@@ -76,8 +103,23 @@ func GetCurrentBranch() (string, error) {
 	return "", nil // Detached HEAD
 }
 
-// SetHEADToBranch sets HEAD to point to a branch reference.
-func SetHEADToBranch(branch string) error {
+// SetHEADToBranch sets HEAD to point to a branch reference and records the move.
+func SetHEADToBranch(branch, action, message string) error {
+	old, err := GetHEAD()
+	if err != nil {
+		return err
+	}
+	if err := writeHEADRef(branch); err != nil {
+		return err
+	}
+	hash, err := GetBranch(branch)
+	if err != nil {
+		return err
+	}
+	return appendReflog(HeadRef, old, hash, action, message)
+}
+
+func writeHEADRef(branch string) error {
 	content := fmt.Sprintf("ref: refs/heads/%s\n", branch)
 	return os.WriteFile(HeadFile, []byte(content), 0644)
 }
@@ -87,9 +129,30 @@ func GetBranch(name string) (string, error) {
 	return readRef(filepath.Join(HeadsDir, name))
 }
 
-// SetBranch updates a branch to point to the given commit hash.
-func SetBranch(name, hash string) error {
-	return writeRef(filepath.Join(HeadsDir, name), hash)
+// SetBranch updates a branch to point to the given commit hash and records the move.
+func SetBranch(name, hash, action, message string) error {
+	old, err := GetBranch(name)
+	if err != nil {
+		return err
+	}
+	if err := writeRef(filepath.Join(HeadsDir, name), hash); err != nil {
+		return err
+	}
+	return appendReflog(BranchRef(name), old, hash, action, message)
+}
+
+// DeleteBranch removes a branch. Its reflog is kept (with a final entry)
+// so the commits it pointed to can still be found and recovered; gc keeps
+// them until the reflog entries expire.
+func DeleteBranch(name string) error {
+	old, err := GetBranch(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(HeadsDir, name)); err != nil {
+		return err
+	}
+	return appendReflog(BranchRef(name), old, "", "branch", "deleted")
 }
 
 // ListBranches returns all branch names.
@@ -111,13 +174,66 @@ func ListBranches() ([]string, error) {
 	return branches, nil
 }
 
+// AutoName returns the short name of the auto-snapshot ref of a branch, as
+// accepted by revisions: "auto/<branch>", or "auto/HEAD" when detached.
+func AutoName(branch string) string {
+	if branch == "" {
+		branch = HeadRef
+	}
+	return "auto/" + branch
+}
+
+// GetAuto returns the commit hash of the auto-snapshot ref of a branch
+// (empty for a detached HEAD).
+func GetAuto(branch string) (string, error) {
+	return readRef(filepath.Join(TraceDir, "refs", AutoName(branch)))
+}
+
+// SetAuto moves the auto-snapshot ref of a branch and records the move.
+func SetAuto(branch, hash, message string) error {
+	old, err := GetAuto(branch)
+	if err != nil {
+		return err
+	}
+	if err := writeRef(filepath.Join(TraceDir, "refs", AutoName(branch)), hash); err != nil {
+		return err
+	}
+	return appendReflog("refs/"+AutoName(branch), old, hash, "auto-snap", message)
+}
+
+// ListAutoRefs returns the names of the branches that have auto-snapshots.
+func ListAutoRefs() ([]string, error) {
+	var branches []string
+	err := filepath.WalkDir(AutoDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(AutoDir, path)
+		if err != nil {
+			return err
+		}
+		branches = append(branches, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return branches, nil
+}
+
 // InitRefs creates the refs directory structure and sets up default branch.
 func InitRefs() error {
 	if err := os.MkdirAll(HeadsDir, 0755); err != nil {
 		return err
 	}
 	// Set HEAD to point to main branch (even though it doesn't exist yet)
-	return SetHEADToBranch(DefaultRef)
+	return writeHEADRef(DefaultRef)
 }
 
 func readRef(path string) (string, error) {
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LogsDir holds one reflog per ref, mirroring the refs layout (logs/HEAD, logs/refs/heads/<branch>).
+const LogsDir = ".trace/logs"
+
+// HeadRef is the reflog name for HEAD.
+const HeadRef = "HEAD"
+
+// ReflogEntry records a single movement of a ref.
+type ReflogEntry struct {
+	Old       string `json:"old"`
+	New       string `json:"new"`
+	Timestamp string `json:"timestamp"`
+	Action    string `json:"action"` // e.g. snap, checkout, branch
+	Message   string `json:"message,omitempty"`
+}
+
+// BranchRef returns the full ref name of a branch, as used for reflogs.
+func BranchRef(name string) string {
+	return "refs/heads/" + name
+}
+
+// ReadReflog returns the entries of a ref's reflog, newest first.
+// A name without a "refs/" prefix (other than HEAD and auto/<branch>) is
+// treated as a branch.
+func ReadReflog(ref string) ([]ReflogEntry, error) {
+	file, err := os.Open(reflogPath(ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []ReflogEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e ReflogEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			// Skip a torn final line rather than losing the whole log
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Reverse so the newest entry comes first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// ListReflogs returns the names of all refs that have a reflog.
+func ListReflogs() ([]string, error) {
+	var refs []string
+	err := filepath.WalkDir(LogsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(LogsDir, path)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// Before reports whether the entry was recorded before cutoff. Entries
+// without a valid timestamp never are.
+func (e ReflogEntry) Before(cutoff time.Time) bool {
+	t, err := time.Parse(time.RFC3339, e.Timestamp)
+	return err == nil && t.Before(cutoff)
+}
+
+// RefExists reports whether the ref a reflog belongs to still exists. HEAD
+// always does; a branch or auto-snapshot ref is gone once deleted.
+func RefExists(ref string) bool {
+	if ref == HeadRef {
+		return true
+	}
+	// Reflogs mirror the refs layout under .trace
+	rel, err := filepath.Rel(LogsDir, reflogPath(ref))
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(TraceDir, rel))
+	return err == nil
+}
+
+// ExpireReflog removes the entries of a ref's reflog recorded before cutoff
+// and returns how many were removed.
+func ExpireReflog(ref string, cutoff time.Time) (int, error) {
+	entries, err := ReadReflog(ref)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	// Rewrite oldest first, as appended
+	var buf []byte
+	expired := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Before(cutoff) {
+			expired++
+			continue
+		}
+		data, err := json.Marshal(entries[i])
+		if err != nil {
+			return 0, err
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+	if expired == 0 {
+		return 0, nil
+	}
+
+	path := reflogPath(ref)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0644); err != nil {
+		return 0, fmt.Errorf("write reflog: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("write reflog: %w", err)
+	}
+	return expired, nil
+}
+
+// DeleteReflog removes the reflog of a ref.
+func DeleteReflog(ref string) error {
+	err := os.Remove(reflogPath(ref))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// appendReflog records a ref movement.
+func appendReflog(ref, oldHash, newHash, action, message string) error {
+	path := reflogPath(ref)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create reflog dir: %w", err)
+	}
+
+	data, err := json.Marshal(ReflogEntry{
+		Old:       oldHash,
+		New:       newHash,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Action:    action,
+		Message:   message,
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open reflog: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write reflog: %w", err)
+	}
+	return nil
+}
+
+func reflogPath(ref string) string {
+	switch {
+	case strings.HasPrefix(ref, "auto/"):
+		ref = "refs/" + ref
+	case ref != HeadRef && !strings.HasPrefix(ref, "refs/"):
+		ref = BranchRef(ref)
+	}
+	return filepath.Join(LogsDir, filepath.FromSlash(ref))
+}
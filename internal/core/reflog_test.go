@@ -0,0 +1,53 @@
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReflog(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "trace_reflog_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(tmpDir)
+
+	if err := InitRefs(); err != nil {
+		t.Fatal(err)
+	}
+
+	SetHEAD("aaaa", "snap", "first")
+	SetHEAD("bbbb", "snap", "second")
+	SetBranch("feature", "aaaa", "branch", "created")
+	DeleteBranch("feature")
+
+	head, err := ReadReflog(HeadRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(head) != 2 {
+		t.Fatalf("Expected 2 HEAD entries, got %d", len(head))
+	}
+	if head[0].New != "bbbb" || head[0].Old != "aaaa" || head[0].Message != "second" {
+		t.Errorf("Newest HEAD entry = %+v", head[0])
+	}
+
+	main, _ := ReadReflog(DefaultRef)
+	if len(main) != 2 {
+		t.Errorf("Expected branch reflog to mirror HEAD moves, got %d entries", len(main))
+	}
+
+	feature, _ := ReadReflog("feature")
+	if len(feature) != 2 || feature[0].New != "" || feature[0].Old != "aaaa" {
+		t.Errorf("Expected deleted branch reflog to be kept, got %+v", feature)
+	}
+
+	refs, _ := ListReflogs()
+	if len(refs) != 3 {
+		t.Errorf("Expected 3 reflogs, got %v", refs)
+	}
+}
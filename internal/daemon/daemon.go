@@ -0,0 +1,208 @@
+// Package daemon serves the state of a trace project as JSON over a Unix
+// socket and streams changes to it as server-sent events, so editors and
+// status bars don't have to run trace for every refresh.
+//
+// Endpoints:
+//
+//	GET  /status                 Drift, policy, ports, health and processes
+//	GET  /diff?from=&to=         Env, file and port differences
+//	GET  /log?rev=&n=            Commit history
+//	GET  /processes              Processes running in the project
+//	POST /kill {"target": "…"}   Kill a process by PID or port
+//	POST /snap {"message": "…"}  Create a snapshot
+//	GET  /events                 Server-sent events, one per change
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SocketName is the name of the socket under .trace.
+const SocketName = "daemon.sock"
+
+// keepAlive is how often an idle event stream gets a comment, so clients
+// and proxies notice dead connections.
+const keepAlive = 30 * time.Second
+
+// Service provides the data the daemon serves. Results are encoded as JSON.
+type Service interface {
+	Status() (any, error)
+	Diff(from, to string) (any, error)
+	Log(rev string, count int) (any, error)
+	Processes() (any, error)
+	Kill(target string) (any, error)
+	Snap(message string) (any, error)
+}
+
+// Event is a change pushed to /events subscribers.
+type Event struct {
+	Type string    `json:"type"` // e.g. "drift", "processes", "snap", "kill"
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// Server routes API requests to a Service and fans events out to streams.
+type Server struct {
+	svc Service
+
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+// New returns a server for svc.
+func New(svc Service) *Server {
+	return &Server{svc: svc, subs: make(map[chan Event]struct{})}
+}
+
+// Publish sends an event to every connected stream. Streams too slow to
+// keep up miss events rather than blocking the daemon.
+func (s *Server) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		respond(w)(s.svc.Status())
+	})
+	mux.HandleFunc("GET /diff", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		respond(w)(s.svc.Diff(q.Get("from"), q.Get("to")))
+	})
+	mux.HandleFunc("GET /log", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		count := 0
+		if n := q.Get("n"); n != "" {
+			var err error
+			if count, err = strconv.Atoi(n); err != nil || count < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid n '%s'", n))
+				return
+			}
+		}
+		respond(w)(s.svc.Log(q.Get("rev"), count))
+	})
+	mux.HandleFunc("GET /processes", func(w http.ResponseWriter, r *http.Request) {
+		respond(w)(s.svc.Processes())
+	})
+	mux.HandleFunc("POST /kill", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Target string `json:"target"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Target == "" {
+			writeError(w, http.StatusBadRequest, errors.New(`expected {"target": "<pid|port>"}`))
+			return
+		}
+		respond(w)(s.svc.Kill(req.Target))
+	})
+	mux.HandleFunc("POST /snap", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Message == "" {
+			writeError(w, http.StatusBadRequest, errors.New(`expected {"message": "..."}`))
+			return
+		}
+		respond(w)(s.svc.Snap(req.Message))
+	})
+	mux.HandleFunc("GET /events", s.events)
+	return mux
+}
+
+// events streams published events until the client disconnects.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+
+	ch := make(chan Event, 16)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case e := <-ch:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// Listen opens the socket at path. A socket left behind by a daemon that
+// died is replaced; a live one is an error.
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	// Only the owner may talk to the daemon: it can kill processes
+	return listenPrivate(path)
+}
+
+func respond(w http.ResponseWriter) func(any, error) {
+	return func(v any, err error) {
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
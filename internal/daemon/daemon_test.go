@@ -0,0 +1,183 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeService struct {
+	killed []string
+}
+
+func (f *fakeService) Status() (any, error) {
+	return map[string]bool{"clean": true}, nil
+}
+
+func (f *fakeService) Diff(from, to string) (any, error) {
+	return map[string]string{"from": from, "to": to}, nil
+}
+
+func (f *fakeService) Log(rev string, count int) (any, error) {
+	if rev == "nope" {
+		return nil, errors.New("commit not found: nope")
+	}
+	return []int{count}, nil
+}
+
+func (f *fakeService) Processes() (any, error) {
+	return []string{}, nil
+}
+
+func (f *fakeService) Kill(target string) (any, error) {
+	f.killed = append(f.killed, target)
+	return map[string]string{"killed": target}, nil
+}
+
+func (f *fakeService) Snap(message string) (any, error) {
+	return map[string]string{"message": message}, nil
+}
+
+func start(t *testing.T) (*Server, *fakeService, *http.Client) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), SocketName)
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("Socket mode = %v, want access for its owner only", info.Mode().Perm())
+	}
+	svc := &fakeService{}
+	srv := New(svc)
+	hs := &http.Server{Handler: srv.Handler()}
+	go hs.Serve(l)
+	t.Cleanup(func() { hs.Close() })
+
+	if _, err := Listen(path); err == nil {
+		t.Error("Listening twice on a live socket should fail")
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	return srv, svc, client
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	resp, err := client.Get("http://trace" + url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, strings.TrimSpace(string(body))
+}
+
+func TestAPI(t *testing.T) {
+	_, svc, client := start(t)
+
+	tests := []struct {
+		url  string
+		code int
+		body string
+	}{
+		{"/status", 200, `{"clean":true}`},
+		{"/diff?from=HEAD~1", 200, `{"from":"HEAD~1","to":""}`},
+		{"/log?n=3", 200, `[3]`},
+		{"/log?n=x", 400, `{"error":"invalid n 'x'"}`},
+		{"/log?rev=nope", 500, `{"error":"commit not found: nope"}`},
+		{"/processes", 200, `[]`},
+	}
+	for _, tt := range tests {
+		code, body := get(t, client, tt.url)
+		if code != tt.code || body != tt.body {
+			t.Errorf("GET %s = %d %s, want %d %s", tt.url, code, body, tt.code, tt.body)
+		}
+	}
+
+	resp, err := client.Post("http://trace/kill", "application/json", strings.NewReader(`{"target": "8080"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || len(svc.killed) != 1 || svc.killed[0] != "8080" {
+		t.Errorf("POST /kill = %d, killed %v", resp.StatusCode, svc.killed)
+	}
+
+	resp, err = client.Post("http://trace/snap", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 400 {
+		t.Errorf("POST /snap without message = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	srv, _, client := start(t)
+
+	resp, err := client.Get("http://trace/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %s", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if line, _ := reader.ReadString('\n'); line != ": connected\n" {
+		t.Fatalf("First line = %q", line)
+	}
+	reader.ReadString('\n')
+
+	srv.Publish(Event{Type: "drift", Data: map[string]bool{"clean": false}})
+
+	done := make(chan struct{})
+	var name, data string
+	go func() {
+		defer close(done)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\n" {
+				return
+			}
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				name = strings.TrimSpace(v)
+			}
+			if v, ok := strings.CutPrefix(line, "data: "); ok {
+				data = strings.TrimSpace(v)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("No event received")
+	}
+
+	var e Event
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		t.Fatalf("Bad event data %q: %v", data, err)
+	}
+	if name != "drift" || e.Type != "drift" || e.Time.IsZero() {
+		t.Errorf("Event = %s %+v", name, e)
+	}
+}
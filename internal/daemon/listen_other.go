@@ -0,0 +1,11 @@
+//go:build !unix
+
+package daemon
+
+import "net"
+
+// listenPrivate opens the socket; file modes do not restrict who may
+// connect to it outside Unix.
+func listenPrivate(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
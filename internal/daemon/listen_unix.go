@@ -0,0 +1,17 @@
+//go:build unix
+
+package daemon
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenPrivate creates the socket with mode 0600 from the start. Changing
+// its mode after net.Listen would leave a window in which other users can
+// connect. The umask is process-wide, which is fine while the daemon starts.
+func listenPrivate(path string) (net.Listener, error) {
+	old := syscall.Umask(0177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -1,27 +1,35 @@
 package diff
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 
 	"trace/internal/core"
 )
 
 // EnvDiff holds the differences between environment keys.
 type EnvDiff struct {
-	Added   []string
-	Removed []string
-	Changed []string // Keys that exist in both but have different value hashes
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"` // Keys that exist in both but have different value hashes
+
+	// Sources notes which env file a key comes from, e.g.
+	// ".env.local overrides .env". Only set when several env files are tracked.
+	Sources map[string]string `json:"sources,omitempty"`
 }
 
 // FileDiff holds the differences between tracked files.
 type FileDiff struct {
-	Added    []string
-	Removed  []string
-	Modified []string
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
 }
 
 // CompareEnv compares environment keys between two snapshots.
+// Each list in the result is sorted.
 func CompareEnv(oldKeys, newKeys map[string]string) EnvDiff {
 	var added, removed, changed []string
 
@@ -42,6 +50,10 @@ func CompareEnv(oldKeys, newKeys map[string]string) EnvDiff {
 		}
 	}
 
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+
 	return EnvDiff{
 		Added:   added,
 		Removed: removed,
@@ -50,6 +62,7 @@ func CompareEnv(oldKeys, newKeys map[string]string) EnvDiff {
 }
 
 // CompareFiles compares tracked files between two snapshots.
+// Each list in the result is sorted.
 func CompareFiles(oldFiles, newFiles map[string]string) FileDiff {
 	var added, removed, modified []string
 
@@ -70,6 +83,10 @@ func CompareFiles(oldFiles, newFiles map[string]string) FileDiff {
 		}
 	}
 
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(modified)
+
 	return FileDiff{
 		Added:    added,
 		Removed:  removed,
@@ -82,18 +99,61 @@ func CompareSnapshots(old, new *core.Snapshot) (EnvDiff, FileDiff) {
 	oldEnv := make(map[string]string)
 	oldFiles := make(map[string]string)
 	if old != nil {
-		oldEnv = old.EnvKeys
+		oldEnv = old.AllEnvKeys()
 		oldFiles = old.Files
 	}
 
 	newEnv := make(map[string]string)
 	newFiles := make(map[string]string)
 	if new != nil {
-		newEnv = new.EnvKeys
+		newEnv = new.AllEnvKeys()
 		newFiles = new.Files
 	}
 
-	return CompareEnv(oldEnv, newEnv), CompareFiles(oldFiles, newFiles)
+	envDiff := CompareEnv(oldEnv, newEnv)
+	envDiff.Sources = envSources(old, new, envDiff)
+	return envDiff, CompareFiles(oldFiles, newFiles)
+}
+
+// envSources describes where each changed key is defined: in the new snapshot
+// for added and changed keys, in the old one for removed keys.
+func envSources(old, new *core.Snapshot, d EnvDiff) map[string]string {
+	sources := make(map[string]string)
+	note := func(s *core.Snapshot, key string) {
+		if s == nil {
+			return
+		}
+		files := s.EnvSources(key)
+		switch {
+		case len(files) > 1:
+			sources[key] = fmt.Sprintf("%s overrides %s", files[0], strings.Join(files[1:], ", "))
+		case len(files) == 1 && len(s.EnvFiles) > 1:
+			sources[key] = files[0]
+		}
+	}
+
+	for _, k := range d.Added {
+		note(new, k)
+	}
+	for _, k := range d.Changed {
+		note(new, k)
+	}
+	for _, k := range d.Removed {
+		note(old, k)
+	}
+
+	if len(sources) == 0 {
+		return nil
+	}
+	return sources
+}
+
+// Label returns key annotated with the env file it comes from, if known.
+func (d EnvDiff) Label(key string) string {
+	if source, ok := d.Sources[key]; ok {
+		return fmt.Sprintf("%s (%s)", key, source)
+	}
+	return key
 }
 
 // RenderEnvDiff prints environment differences.
@@ -103,13 +163,13 @@ func RenderEnvDiff(w io.Writer, d EnvDiff) {
 	}
 
 	for _, k := range d.Added {
-		fmt.Fprintf(w, "  \033[32m+ [ENV ADDED]\033[0m   %s\n", k)
+		fmt.Fprintf(w, "  \033[32m+ [ENV ADDED]\033[0m   %s\n", d.Label(k))
 	}
 	for _, k := range d.Removed {
-		fmt.Fprintf(w, "  \033[31m- [ENV REMOVED]\033[0m %s\n", k)
+		fmt.Fprintf(w, "  \033[31m- [ENV REMOVED]\033[0m %s\n", d.Label(k))
 	}
 	for _, k := range d.Changed {
-		fmt.Fprintf(w, "  \033[33m* [ENV CHANGED]\033[0m %s\n", k)
+		fmt.Fprintf(w, "  \033[33m* [ENV CHANGED]\033[0m %s\n", d.Label(k))
 	}
 }
 
@@ -139,3 +199,31 @@ func (d EnvDiff) IsEmpty() bool {
 func (d FileDiff) IsEmpty() bool {
 	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
 }
+
+// MarshalJSON encodes the diff with empty lists instead of null.
+func (d EnvDiff) MarshalJSON() ([]byte, error) {
+	type plain EnvDiff
+	return json.Marshal(plain{
+		Added:   orEmpty(d.Added),
+		Removed: orEmpty(d.Removed),
+		Changed: orEmpty(d.Changed),
+		Sources: d.Sources,
+	})
+}
+
+// MarshalJSON encodes the diff with empty lists instead of null.
+func (d FileDiff) MarshalJSON() ([]byte, error) {
+	type plain FileDiff
+	return json.Marshal(plain{
+		Added:    orEmpty(d.Added),
+		Removed:  orEmpty(d.Removed),
+		Modified: orEmpty(d.Modified),
+	})
+}
+
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"trace/internal/dotenv"
+)
+
+// CompareEnvFile reports the keys added, removed or changed between two
+// versions of a .env file. Values are never included: Old and New stay empty.
+// A nil version is treated as an empty file.
+func CompareEnvFile(old, new []byte) []Change {
+	oldValues := dotenv.Parse(old).Values()
+	newValues := dotenv.Parse(new).Values()
+
+	var changes []Change
+	for key, value := range newValues {
+		oldValue, ok := oldValues[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: key, Kind: ChangeAdded})
+		case oldValue != value:
+			changes = append(changes, Change{Path: key, Kind: ChangeChanged})
+		}
+	}
+	for key := range oldValues {
+		if _, ok := newValues[key]; !ok {
+			changes = append(changes, Change{Path: key, Kind: ChangeRemoved})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// RenderEnvChanges writes the key changes of a .env file with masked values.
+func RenderEnvChanges(w io.Writer, path string, changes []Change) {
+	fmt.Fprintf(w, "\033[1m%s\033[0m\n", path)
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "  (no key changes, comments or formatting only)")
+		return
+	}
+	for _, c := range changes {
+		switch c.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(w, "  \033[32m+ %s\033[0m=<added>\n", c.Path)
+		case ChangeRemoved:
+			fmt.Fprintf(w, "  \033[31m- %s\033[0m=<removed>\n", c.Path)
+		case ChangeChanged:
+			fmt.Fprintf(w, "  \033[33m~ %s\033[0m=<changed>\n", c.Path)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package diff
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareEnvFile(t *testing.T) {
+	old := []byte("# db\nPORT=3000\nAPI_KEY=old-secret\nDEBUG=true\n")
+	new := []byte("# database\nPORT=3000\nAPI_KEY=new-secret\nHOST=example.com\n")
+
+	changes := CompareEnvFile(old, new)
+	want := []Change{
+		{Path: "API_KEY", Kind: ChangeChanged},
+		{Path: "DEBUG", Kind: ChangeRemoved},
+		{Path: "HOST", Kind: ChangeAdded},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("CompareEnvFile() = %+v, want %+v", changes, want)
+	}
+
+	var buf bytes.Buffer
+	RenderEnvChanges(&buf, ".env", changes)
+	out := buf.String()
+	for _, value := range []string{"old-secret", "new-secret", "example.com", "true"} {
+		if strings.Contains(out, value) {
+			t.Errorf("Rendered changes reveal %q:\n%s", value, out)
+		}
+	}
+	if !strings.Contains(out, "API_KEY\033[0m=<changed>") {
+		t.Errorf("Expected API_KEY to be marked as changed:\n%s", out)
+	}
+
+	if changes := CompareEnvFile(nil, nil); len(changes) != 0 {
+		t.Errorf("Expected no changes between empty files, got %+v", changes)
+	}
+}
@@ -0,0 +1,297 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LineOp is the kind of change a diff line represents.
+type LineOp int
+
+const (
+	LineEqual LineOp = iota
+	LineInsert
+	LineDelete
+)
+
+// Line is a single line of a line-level diff.
+type Line struct {
+	Op     LineOp
+	Text   string
+	OldNum int // 1-based line number in the old version, 0 for inserts
+	NewNum int // 1-based line number in the new version, 0 for deletes
+}
+
+// Hunk is a group of changed lines with surrounding context.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []Line
+}
+
+// DefaultContext is the number of unchanged lines shown around each change.
+const DefaultContext = 3
+
+// MaxEditDistance bounds the work of LineDiff. The search keeps a trace
+// whose size grows with the square of the number of inserted and deleted
+// lines; past this many, a file is reported as rewritten instead.
+const MaxEditDistance = 2000
+
+// LineDiff computes the shortest edit script between two sets of lines
+// using Myers' O(ND) algorithm. If more than MaxEditDistance lines would
+// be inserted or deleted, it gives up, deletes every old line, inserts every
+// new one and reports the file as rewritten.
+func LineDiff(a, b []string) (lines []Line, rewritten bool) {
+	n, m := len(a), len(b)
+	limit := min(n+m, MaxEditDistance)
+	offset := n + m + 1
+	v := make([]int, 2*(n+m)+3)
+	// trace[d] holds v[offset-d : offset+d+1] as it was before step d
+	var trace [][]int
+
+	found := false
+search:
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Step down: insertion
+			} else {
+				x = v[offset+k-1] + 1 // Step right: deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break search
+			}
+		}
+	}
+	if !found {
+		return rewrite(a, b), true
+	}
+
+	// Walk the trace backwards to recover the edit script
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, Line{Op: LineEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			lines = append(lines, Line{Op: LineInsert, Text: b[y-1]})
+		} else {
+			lines = append(lines, Line{Op: LineDelete, Text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	// What is left is the common prefix
+	for ; x > 0; x-- {
+		lines = append(lines, Line{Op: LineEqual, Text: a[x-1]})
+	}
+
+	// Reverse into forward order and number the lines
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	oldNum, newNum := 0, 0
+	for i := range lines {
+		switch lines[i].Op {
+		case LineEqual:
+			oldNum++
+			newNum++
+			lines[i].OldNum, lines[i].NewNum = oldNum, newNum
+		case LineDelete:
+			oldNum++
+			lines[i].OldNum = oldNum
+		case LineInsert:
+			newNum++
+			lines[i].NewNum = newNum
+		}
+	}
+
+	return lines, false
+}
+
+// rewrite returns the edit script that deletes all of a and inserts all of b.
+func rewrite(a, b []string) []Line {
+	lines := make([]Line, 0, len(a)+len(b))
+	for i, text := range a {
+		lines = append(lines, Line{Op: LineDelete, Text: text, OldNum: i + 1})
+	}
+	for i, text := range b {
+		lines = append(lines, Line{Op: LineInsert, Text: text, NewNum: i + 1})
+	}
+	return lines
+}
+
+// Hunks groups a line diff into hunks with the given number of context lines.
+func Hunks(lines []Line, context int) []Hunk {
+	var hunks []Hunk
+
+	i := 0
+	for i < len(lines) {
+		// Find the next change
+		for i < len(lines) && lines[i].Op == LineEqual {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+
+		start := max(i-context, 0)
+		end := i
+		// Extend while the next change is close enough to share context
+		for end < len(lines) {
+			if lines[end].Op != LineEqual {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].Op == LineEqual {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			end = next
+		}
+
+		hunks = append(hunks, newHunk(lines, start, end))
+		i = end
+	}
+
+	return hunks
+}
+
+func newHunk(lines []Line, start, end int) Hunk {
+	h := Hunk{Lines: lines[start:end]}
+
+	oldBefore, newBefore := 0, 0
+	for _, l := range lines[:start] {
+		if l.Op != LineInsert {
+			oldBefore++
+		}
+		if l.Op != LineDelete {
+			newBefore++
+		}
+	}
+	for _, l := range h.Lines {
+		if l.Op != LineInsert {
+			h.OldLines++
+		}
+		if l.Op != LineDelete {
+			h.NewLines++
+		}
+	}
+
+	h.OldStart, h.NewStart = oldBefore, newBefore
+	if h.OldLines > 0 {
+		h.OldStart++
+	}
+	if h.NewLines > 0 {
+		h.NewStart++
+	}
+	return h
+}
+
+// SplitLines splits file content into lines without their line endings.
+func SplitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
+
+// IsBinary reports whether content looks like binary data.
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// RenderUnified writes a colored unified diff between two versions of a file.
+// A nil old or new version is shown as /dev/null (file added or removed).
+func RenderUnified(w io.Writer, path string, old, new []byte, context int) {
+	oldName, newName := "a/"+path, "b/"+path
+	if old == nil {
+		oldName = "/dev/null"
+	}
+	if new == nil {
+		newName = "/dev/null"
+	}
+
+	fmt.Fprintf(w, "\033[1mdiff --trace a/%s b/%s\033[0m\n", path, path)
+
+	if IsBinary(old) || IsBinary(new) {
+		fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return
+	}
+
+	fmt.Fprintf(w, "\033[1m--- %s\033[0m\n", oldName)
+	fmt.Fprintf(w, "\033[1m+++ %s\033[0m\n", newName)
+
+	oldLines, newLines := SplitLines(old), SplitLines(new)
+	lines, rewritten := LineDiff(oldLines, newLines)
+	if rewritten {
+		fmt.Fprintf(w, "\033[90mFile rewritten: %d lines removed, %d added (too many changes to show line by line)\033[0m\n", len(oldLines), len(newLines))
+		return
+	}
+	for _, h := range Hunks(lines, context) {
+		fmt.Fprintf(w, "\033[36m@@ -%d,%d +%d,%d @@\033[0m\n", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+		for _, l := range h.Lines {
+			switch l.Op {
+			case LineEqual:
+				fmt.Fprintf(w, " %s\n", highlight(l.Text))
+			case LineInsert:
+				fmt.Fprintf(w, "\033[32m+%s\033[0m\n", l.Text)
+			case LineDelete:
+				fmt.Fprintf(w, "\033[31m-%s\033[0m\n", l.Text)
+			}
+		}
+	}
+}
+
+// highlight applies light syntax coloring to an unchanged line of a config
+// file: comments are dimmed and the key of KEY=VALUE / key: value lines is colored.
+func highlight(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "//") {
+		return "\033[90m" + line + "\033[0m"
+	}
+
+	idx := strings.IndexAny(line, "=:")
+	if idx <= 0 {
+		return line
+	}
+	key := strings.TrimSpace(line[:idx])
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "export "), "- ")
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return line
+	}
+	return "\033[34m" + line[:idx] + "\033[0m" + line[idx:]
+}
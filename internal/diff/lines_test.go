@@ -0,0 +1,113 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		old, new string
+		want     string // Ops as " ", "+", "-" joined
+	}{
+		{"", "", ""},
+		{"a\nb\nc\n", "a\nb\nc\n", "   "},
+		{"", "a\nb\n", "++"},
+		{"a\nb\n", "", "--"},
+		{"a\nb\nc\n", "a\nx\nc\n", " -+ "},
+		{"a\nb\nc\nd\n", "a\nc\nd\ne\n", " -  +"},
+	}
+
+	ops := map[LineOp]string{LineEqual: " ", LineInsert: "+", LineDelete: "-"}
+
+	for _, tt := range tests {
+		lines, _ := LineDiff(SplitLines([]byte(tt.old)), SplitLines([]byte(tt.new)))
+		var got strings.Builder
+		for _, l := range lines {
+			got.WriteString(ops[l.Op])
+		}
+		if got.String() != tt.want {
+			t.Errorf("LineDiff(%q, %q) = %q, want %q", tt.old, tt.new, got.String(), tt.want)
+		}
+	}
+}
+
+func TestLineDiffLarge(t *testing.T) {
+	// A long file with a few changes is diffed line by line
+	var old, new []string
+	for i := 0; i < 20000; i++ {
+		old = append(old, fmt.Sprintf("line %d", i))
+	}
+	new = append(new, old...)
+	new[100] = "changed"
+	new = append(new[:5000], new[5010:]...)
+	lines, rewritten := LineDiff(old, new)
+	if rewritten {
+		t.Fatal("Expected a line diff of a few changes")
+	}
+	var inserted, deleted int
+	for _, l := range lines {
+		switch l.Op {
+		case LineInsert:
+			inserted++
+		case LineDelete:
+			deleted++
+		}
+	}
+	if inserted != 1 || deleted != 11 {
+		t.Errorf("Expected 1 insertion and 11 deletions, got %d and %d", inserted, deleted)
+	}
+
+	// A 5k-line rewrite gives up instead of tracing every step
+	old, new = old[:5000], nil
+	for i := 0; i < 5000; i++ {
+		new = append(new, fmt.Sprintf("other %d", i))
+	}
+	lines, rewritten = LineDiff(old, new)
+	if !rewritten || len(lines) != 10000 {
+		t.Fatalf("Expected a rewrite of 10000 lines, got %d lines (rewritten %v)", len(lines), rewritten)
+	}
+	if lines[0].Op != LineDelete || lines[0].OldNum != 1 || lines[9999].Op != LineInsert || lines[9999].NewNum != 5000 {
+		t.Errorf("Unexpected rewrite %+v ... %+v", lines[0], lines[9999])
+	}
+
+	var out strings.Builder
+	RenderUnified(&out, "package-lock.json", []byte(strings.Join(old, "\n")), []byte(strings.Join(new, "\n")), DefaultContext)
+	if !strings.Contains(out.String(), "File rewritten: 5000 lines removed, 5000 added") {
+		t.Errorf("RenderUnified() = %q", out.String())
+	}
+}
+
+func TestHunks(t *testing.T) {
+	var old, new []string
+	for i := 0; i < 20; i++ {
+		line := string(rune('a' + i))
+		old = append(old, line)
+		new = append(new, line)
+	}
+	new[2] = "X"  // First change
+	new[15] = "Y" // Far enough away for a second hunk
+
+	lines, _ := LineDiff(old, new)
+	hunks := Hunks(lines, 3)
+	if len(hunks) != 2 {
+		t.Fatalf("Expected 2 hunks, got %d", len(hunks))
+	}
+
+	h := hunks[0]
+	if h.OldStart != 1 || h.OldLines != 6 || h.NewStart != 1 || h.NewLines != 6 {
+		t.Errorf("First hunk header = -%d,%d +%d,%d", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	}
+	h = hunks[1]
+	if h.OldStart != 13 || h.OldLines != 7 {
+		t.Errorf("Second hunk header = -%d,%d", h.OldStart, h.OldLines)
+	}
+
+	// Changes within 2*context lines of each other share a hunk
+	new[8] = "Z"
+	lines, _ = LineDiff(old, new)
+	if hunks := Hunks(lines, 3); len(hunks) != 1 {
+		t.Errorf("Expected nearby changes to merge, got %d hunks", len(hunks))
+	}
+}
@@ -0,0 +1,89 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"trace/internal/core"
+)
+
+// PortDiff holds the differences between the ports recorded in two snapshots.
+// Entries are formatted as "8080/tcp (node)".
+type PortDiff struct {
+	Opened  []string `json:"opened"`
+	Closed  []string `json:"closed"`
+	Changed []string `json:"changed"` // Same port, now owned by another process
+}
+
+// ComparePorts compares the ports recorded in two snapshots.
+func ComparePorts(old, new *core.Snapshot) PortDiff {
+	oldPorts := make(map[string]core.PortBinding)
+	newPorts := make(map[string]core.PortBinding)
+	if old != nil {
+		for _, b := range old.Ports {
+			oldPorts[b.String()] = b
+		}
+	}
+	if new != nil {
+		for _, b := range new.Ports {
+			newPorts[b.String()] = b
+		}
+	}
+
+	var d PortDiff
+	if new != nil {
+		for _, b := range new.Ports {
+			prev, existed := oldPorts[b.String()]
+			switch {
+			case !existed:
+				d.Opened = append(d.Opened, portLabel(b))
+			case prev.Process != b.Process:
+				d.Changed = append(d.Changed, fmt.Sprintf("%s (%s -> %s)", b, prev.Process, b.Process))
+			}
+		}
+	}
+	if old != nil {
+		for _, b := range old.Ports {
+			if _, exists := newPorts[b.String()]; !exists {
+				d.Closed = append(d.Closed, portLabel(b))
+			}
+		}
+	}
+	return d
+}
+
+func portLabel(b core.PortBinding) string {
+	if b.Process == "" {
+		return b.String()
+	}
+	return fmt.Sprintf("%s (%s)", b, b.Process)
+}
+
+// IsEmpty returns true if there are no differences.
+func (d PortDiff) IsEmpty() bool {
+	return len(d.Opened) == 0 && len(d.Closed) == 0 && len(d.Changed) == 0
+}
+
+// RenderPortDiff prints port differences.
+func RenderPortDiff(w io.Writer, d PortDiff) {
+	for _, p := range d.Opened {
+		fmt.Fprintf(w, "  \033[32m+ [PORT OPENED]\033[0m  %s\n", p)
+	}
+	for _, p := range d.Closed {
+		fmt.Fprintf(w, "  \033[31m- [PORT CLOSED]\033[0m  %s\n", p)
+	}
+	for _, p := range d.Changed {
+		fmt.Fprintf(w, "  \033[33m* [PORT CHANGED]\033[0m %s\n", p)
+	}
+}
+
+// MarshalJSON encodes the diff with empty lists instead of null.
+func (d PortDiff) MarshalJSON() ([]byte, error) {
+	type plain PortDiff
+	return json.Marshal(plain{
+		Opened:  orEmpty(d.Opened),
+		Closed:  orEmpty(d.Closed),
+		Changed: orEmpty(d.Changed),
+	})
+}
@@ -0,0 +1,34 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"trace/internal/core"
+)
+
+func TestComparePorts(t *testing.T) {
+	old := &core.Snapshot{Ports: []core.PortBinding{
+		{Port: 3000, Protocol: "tcp", Process: "node"},
+		{Port: 5432, Protocol: "tcp", Process: "postgres"},
+		{Port: 8080, Protocol: "tcp", Process: "node"},
+	}}
+	new := &core.Snapshot{Ports: []core.PortBinding{
+		{Port: 3000, Protocol: "tcp", Process: "node"},
+		{Port: 6379, Protocol: "tcp", Process: "redis-server"},
+		{Port: 8080, Protocol: "tcp", Process: "python3"},
+	}}
+
+	want := PortDiff{
+		Opened:  []string{"6379/tcp (redis-server)"},
+		Closed:  []string{"5432/tcp (postgres)"},
+		Changed: []string{"8080/tcp (node -> python3)"},
+	}
+	if got := ComparePorts(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("ComparePorts() = %+v, want %+v", got, want)
+	}
+
+	if d := ComparePorts(old, old); !d.IsEmpty() {
+		t.Errorf("ComparePorts(same) = %+v, want empty", d)
+	}
+}
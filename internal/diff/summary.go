@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// summaryKeys caps how many env keys Summary lists.
+const summaryKeys = 5
+
+// Summary describes drift in one line, e.g.
+// "modified .env, compose.yml; env +API_KEY ~PORT -DEBUG".
+// It returns an empty string if there are no differences.
+func Summary(env EnvDiff, files FileDiff) string {
+	var parts []string
+	for _, group := range []struct {
+		verb  string
+		paths []string
+	}{
+		{"added", files.Added},
+		{"removed", files.Removed},
+		{"modified", files.Modified},
+	} {
+		if len(group.paths) > 0 {
+			parts = append(parts, group.verb+" "+strings.Join(group.paths, ", "))
+		}
+	}
+
+	var keys []string
+	for _, k := range env.Added {
+		keys = append(keys, "+"+k)
+	}
+	for _, k := range env.Changed {
+		keys = append(keys, "~"+k)
+	}
+	for _, k := range env.Removed {
+		keys = append(keys, "-"+k)
+	}
+	if len(keys) > summaryKeys {
+		keys = append(keys[:summaryKeys], fmt.Sprintf("(%d more)", len(keys)-summaryKeys))
+	}
+	if len(keys) > 0 {
+		parts = append(parts, "env "+strings.Join(keys, " "))
+	}
+
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,29 @@
+package diff
+
+import "testing"
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		env   EnvDiff
+		files FileDiff
+		want  string
+	}{
+		{EnvDiff{}, FileDiff{}, ""},
+		{
+			EnvDiff{Added: []string{"API_KEY"}, Removed: []string{"DEBUG"}, Changed: []string{"PORT"}},
+			FileDiff{Modified: []string{".env", "compose.yml"}},
+			"modified .env, compose.yml; env +API_KEY ~PORT -DEBUG",
+		},
+		{
+			EnvDiff{Added: []string{"A", "B", "C", "D", "E", "F", "G"}},
+			FileDiff{Added: []string{".env.local"}, Removed: []string{"old.ini"}},
+			"added .env.local; removed old.ini; env +A +B +C +D +E (2 more)",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Summary(tt.env, tt.files); got != tt.want {
+			t.Errorf("Summary() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package dotenv parses .env files using the grammar shared by docker-compose
+// and node's dotenv: `export` prefixes, single, double and backtick quotes,
+// multi-line quoted values, escape sequences in double quotes, inline comments
+// and ${VAR} interpolation.
+package dotenv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Entry is a single line (or multi-line assignment) of a .env file.
+type Entry struct {
+	Line    int    // 1-based line the entry starts on
+	Key     string // Empty for comment and blank lines
+	Value   string // Unquoted, unescaped and interpolated value
+	Raw     string // Value as written, including quotes
+	Comment string // Text of a comment line, including the leading '#'
+}
+
+// Warning is a problem found while parsing. The offending line is skipped
+// or parsed as well as possible.
+type Warning struct {
+	Line    int
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d: %s", w.Line, w.Message)
+}
+
+// File is a parsed .env file.
+type File struct {
+	Entries  []Entry
+	Warnings []Warning
+}
+
+// Values returns the final value of every key. Later assignments win.
+func (f *File) Values() map[string]string {
+	values := make(map[string]string)
+	for _, e := range f.Entries {
+		if e.Key != "" {
+			values[e.Key] = e.Value
+		}
+	}
+	return values
+}
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// assignmentPattern matches a line that starts a new KEY=VALUE entry.
+var assignmentPattern = regexp.MustCompile(`^\s*(export\s+)?[A-Za-z_][A-Za-z0-9_.-]*\s*=`)
+
+// Parse parses the content of a .env file. It never fails: malformed lines
+// are reported as warnings. Variables are interpolated from keys defined
+// earlier in the same file.
+func Parse(data []byte) *File {
+	p := &parser{
+		lines:  strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"),
+		vars:   make(map[string]string),
+		lineOf: make(map[string]int),
+	}
+	// A trailing newline does not start another line
+	if n := len(p.lines); n > 0 && p.lines[n-1] == "" {
+		p.lines = p.lines[:n-1]
+	}
+	p.parse()
+	return &p.file
+}
+
+type parser struct {
+	lines  []string
+	pos    int // Index of the next line to read
+	file   File
+	vars   map[string]string
+	lineOf map[string]int // Line of the last assignment of each key
+}
+
+func (p *parser) warn(line int, format string, args ...any) {
+	p.file.Warnings = append(p.file.Warnings, Warning{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) parse() {
+	for p.pos < len(p.lines) {
+		lineNum := p.pos + 1
+		line := strings.TrimSpace(p.lines[p.pos])
+		p.pos++
+
+		if line == "" {
+			p.file.Entries = append(p.file.Entries, Entry{Line: lineNum})
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			p.file.Entries = append(p.file.Entries, Entry{Line: lineNum, Comment: line})
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimSpace(rest)
+		}
+
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			p.warn(lineNum, "expected KEY=VALUE")
+			continue
+		}
+		key := strings.TrimSpace(line[:idx])
+		if !keyPattern.MatchString(key) {
+			p.warn(lineNum, "invalid key %q", key)
+			continue
+		}
+
+		raw, value := p.parseValue(lineNum, strings.TrimLeft(line[idx+1:], " \t"))
+
+		if prev, dup := p.lineOf[key]; dup {
+			p.warn(lineNum, "%s is already set on line %d; this value wins", key, prev)
+		}
+		p.lineOf[key] = lineNum
+		p.vars[key] = value
+		p.file.Entries = append(p.file.Entries, Entry{Line: lineNum, Key: key, Value: value, Raw: raw})
+	}
+}
+
+// parseValue parses the value starting at s, reading further lines for
+// multi-line quoted values. It returns the raw text and the final value.
+// A quote still open at the next KEY= line or at the end of the file is
+// unterminated: like node-dotenv, the value is then the rest of its line,
+// so that a single typo does not swallow the keys that follow.
+func (p *parser) parseValue(lineNum int, s string) (string, string) {
+	if s == "" {
+		return "", ""
+	}
+
+	quote := s[0]
+	if quote != '"' && quote != '\'' && quote != '`' {
+		// Unquoted: an inline comment needs whitespace before the '#'
+		raw := s
+		for i := 1; i < len(s); i++ {
+			if s[i] == '#' && (s[i-1] == ' ' || s[i-1] == '\t') {
+				raw = s[:i]
+				break
+			}
+		}
+		raw = strings.TrimSpace(raw)
+		return raw, p.interpolate(lineNum, raw)
+	}
+
+	// Find the closing quote, continuing on the following lines if needed
+	text := s
+	next := p.pos
+	for {
+		if end := closingQuote(text, quote); end > 0 {
+			trailing := strings.TrimSpace(text[end+1:])
+			if trailing != "" && !strings.HasPrefix(trailing, "#") {
+				p.warn(lineNum, "unexpected characters after closing quote")
+			}
+			raw := text[:end+1]
+			inner := raw[1:end]
+			switch quote {
+			case '"':
+				return raw, p.interpolate(lineNum, unescape(inner))
+			default:
+				return raw, inner
+			}
+		}
+		if p.pos >= len(p.lines) || assignmentPattern.MatchString(p.lines[p.pos]) {
+			p.warn(lineNum, "unterminated %c quote", quote)
+			p.pos = next
+			raw := strings.TrimRight(s, " \t")
+			return raw, raw[1:]
+		}
+		text += "\n" + p.lines[p.pos]
+		p.pos++
+	}
+}
+
+// closingQuote returns the index of the quote closing text[0], or -1.
+// Only double quotes honor backslash escapes.
+func closingQuote(text string, quote byte) int {
+	for i := 1; i < len(text); i++ {
+		if quote == '"' && text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescape resolves the escape sequences of double-quoted values.
+// "\$" is kept so that interpolation can treat it as a literal dollar.
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		case '$':
+			b.WriteString(`\$`)
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+var varPattern = regexp.MustCompile(`\\\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?-)([^}]*))?\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// interpolate expands $VAR, ${VAR}, ${VAR:-default} and ${VAR-default}
+// using keys defined earlier in the file.
+func (p *parser) interpolate(lineNum int, s string) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	return varPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == `\$` {
+			return "$"
+		}
+		m := varPattern.FindStringSubmatch(match)
+		name, op, def := m[1], m[2], m[3]
+		if name == "" {
+			name = m[4]
+		}
+
+		value, ok := p.vars[name]
+		switch {
+		case op == ":-" && value == "", op == "-" && !ok:
+			return def
+		case !ok:
+			p.warn(lineNum, "undefined variable %s (expands to an empty string)", name)
+		}
+		return value
+	})
+}
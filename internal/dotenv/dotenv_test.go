@@ -0,0 +1,130 @@
+package dotenv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseValues(t *testing.T) {
+	input := `# Comment
+export PORT=3000
+HOST = localhost   # inline comment
+COLOR=#fff
+SINGLE='literal $PORT # not a comment'
+DOUBLE="a#b \"quoted\" \$HOME"
+ESCAPES="line1\nline2\ttab"
+JSON='{"a": [1, 2], "b": "c"}'
+MULTI="first
+second"
+BACKTICK=` + "`it's`" + `
+URL=http://${HOST}:$PORT/api
+DEFAULT=${MISSING:-fallback}
+EMPTY=
+`
+
+	f := Parse([]byte(input))
+	want := map[string]string{
+		"PORT":     "3000",
+		"HOST":     "localhost",
+		"COLOR":    "#fff",
+		"SINGLE":   "literal $PORT # not a comment",
+		"DOUBLE":   `a#b "quoted" $HOME`,
+		"ESCAPES":  "line1\nline2\ttab",
+		"JSON":     `{"a": [1, 2], "b": "c"}`,
+		"MULTI":    "first\nsecond",
+		"BACKTICK": "it's",
+		"URL":      "http://localhost:3000/api",
+		"DEFAULT":  "fallback",
+		"EMPTY":    "",
+	}
+
+	if got := f.Values(); !reflect.DeepEqual(got, want) {
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("%s = %q, want %q", k, got[k], v)
+			}
+		}
+		for k := range got {
+			if _, ok := want[k]; !ok {
+				t.Errorf("Unexpected key %s", k)
+			}
+		}
+	}
+	if len(f.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", f.Warnings)
+	}
+}
+
+func TestParseWarnings(t *testing.T) {
+	input := `GOOD=1
+not a pair
+1BAD=x
+GOOD=2
+TRAILING="x" y
+URL=$NOPE
+OPEN="never closed
+`
+
+	f := Parse([]byte(input))
+	var got []string
+	for _, w := range f.Warnings {
+		got = append(got, w.String())
+	}
+
+	wantLines := []string{"line 2:", "line 3:", "line 4:", "line 5:", "line 6:", "line 7:"}
+	if len(got) != len(wantLines) {
+		t.Fatalf("Warnings = %v, want %d", got, len(wantLines))
+	}
+	for i, prefix := range wantLines {
+		if !strings.HasPrefix(got[i], prefix) {
+			t.Errorf("Warning %d = %q, want prefix %q", i, got[i], prefix)
+		}
+	}
+
+	if v := f.Values()["GOOD"]; v != "2" {
+		t.Errorf("GOOD = %q, want the later value", v)
+	}
+}
+
+func TestParseUnterminatedQuote(t *testing.T) {
+	input := `A=1
+SECRET="abc
+B=2
+export C='x
+  still the value of C?
+D=4
+`
+
+	f := Parse([]byte(input))
+	want := map[string]string{"A": "1", "SECRET": "abc", "B": "2", "C": "x", "D": "4"}
+	if got := f.Values(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Values() = %v, want %v", got, want)
+	}
+
+	var got []string
+	for _, w := range f.Warnings {
+		got = append(got, w.String())
+	}
+	if len(got) != 3 || !strings.HasPrefix(got[0], "line 2: unterminated") || !strings.HasPrefix(got[1], "line 4: unterminated") || !strings.HasPrefix(got[2], "line 5:") {
+		t.Errorf("Warnings = %v", got)
+	}
+}
+
+func TestParseEntries(t *testing.T) {
+	f := Parse([]byte("# header\n\nA=\"x\ny\"\nB=2\n"))
+
+	var lines []int
+	for _, e := range f.Entries {
+		lines = append(lines, e.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{1, 2, 3, 5}) {
+		t.Errorf("Entry lines = %v", lines)
+	}
+	if f.Entries[0].Comment != "# header" {
+		t.Errorf("Comment = %q", f.Entries[0].Comment)
+	}
+	if f.Entries[2].Raw != "\"x\ny\"" {
+		t.Errorf("Raw = %q", f.Entries[2].Raw)
+	}
+}
@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"trace/internal/config"
+)
+
+// DefaultHealthTimeout bounds a health check without an explicit timeout.
+const DefaultHealthTimeout = 5 * time.Second
+
+// maxHealthBody limits how much of an HTTP response is read for body matching.
+const maxHealthBody = 1 << 20
+
+// HealthResult is the outcome of one health check.
+type HealthResult struct {
+	Name     string        `json:"name"`
+	Kind     string        `json:"kind"` // "tcp", "http" or "command"
+	Target   string        `json:"target"`
+	Healthy  bool          `json:"healthy"`
+	Detail   string        `json:"detail,omitempty"` // Why the check failed, or what it saw
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// RunHealthChecks runs all checks concurrently from dir, each bounded by its
+// timeout, and returns the results in the order of checks.
+func RunHealthChecks(ctx context.Context, dir string, checks []config.HealthCheck) []HealthResult {
+	results := make([]HealthResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check config.HealthCheck) {
+			defer wg.Done()
+			results[i] = runHealthCheck(ctx, dir, check)
+		}(i, check)
+	}
+	wg.Wait()
+	return results
+}
+
+func runHealthCheck(ctx context.Context, dir string, check config.HealthCheck) HealthResult {
+	result := HealthResult{Name: check.Name}
+
+	timeout := DefaultHealthTimeout
+	if check.Timeout != "" {
+		d, err := time.ParseDuration(check.Timeout)
+		if err != nil {
+			result.Detail = fmt.Sprintf("invalid timeout %q", check.Timeout)
+			return result
+		}
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch {
+	case check.TCP != "" && check.HTTP == "" && check.Command == "":
+		result.Kind, result.Target = "tcp", check.TCP
+		err = checkTCP(ctx, check.TCP)
+	case check.HTTP != "" && check.TCP == "" && check.Command == "":
+		result.Kind, result.Target = "http", check.HTTP
+		result.Detail, err = checkHTTP(ctx, check)
+	case check.Command != "" && check.TCP == "" && check.HTTP == "":
+		result.Kind, result.Target = "command", check.Command
+		err = checkCommand(ctx, dir, check.Command)
+	default:
+		err = fmt.Errorf("set exactly one of tcp, http and command")
+	}
+	result.Duration = time.Since(start)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	result.Healthy = true
+	return result
+}
+
+func checkTCP(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkHTTP returns the response status as detail when the check passes.
+func checkHTTP(ctx context.Context, check config.HealthCheck) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.HTTP, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if check.Status != 0 && resp.StatusCode != check.Status {
+		return "", fmt.Errorf("status %d, expected %d", resp.StatusCode, check.Status)
+	}
+	if check.Status == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	if check.Body != "" {
+		re, err := regexp.Compile(check.Body)
+		if err != nil {
+			return "", fmt.Errorf("invalid body pattern: %w", err)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxHealthBody))
+		if err != nil {
+			return "", fmt.Errorf("read body: %w", err)
+		}
+		if !re.Match(body) {
+			return "", fmt.Errorf("body does not match %s", check.Body)
+		}
+	}
+	return fmt.Sprintf("status %d", resp.StatusCode), nil
+}
+
+func checkCommand(ctx context.Context, dir, command string) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	// Don't wait forever for children of the shell that keep the output open
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if out := strings.TrimSpace(string(output)); out != "" {
+			lines := strings.Split(out, "\n")
+			return fmt.Errorf("%v: %s", err, lines[len(lines)-1])
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"trace/internal/config"
+)
+
+func TestRunHealthChecks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	openAddr := ln.Addr().String()
+	ln.Close()
+	ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	checks := []config.HealthCheck{
+		{Name: "http", HTTP: server.URL + "/health", Body: `"status":"ok"`},
+		{Name: "http-status", HTTP: server.URL + "/down"},
+		{Name: "http-expected", HTTP: server.URL + "/down", Status: 503},
+		{Name: "http-body", HTTP: server.URL + "/health", Body: `"status":"degraded"`},
+		{Name: "tcp", TCP: ln.Addr().String()},
+		{Name: "tcp-closed", TCP: openAddr},
+		{Name: "command", Command: "true"},
+		{Name: "command-fail", Command: "echo broken >&2; exit 3"},
+		{Name: "timeout", Command: "sleep 5", Timeout: "100ms"},
+		{Name: "invalid", TCP: "x", HTTP: "y"},
+	}
+	want := map[string]bool{
+		"http":          true,
+		"http-status":   false,
+		"http-expected": true,
+		"http-body":     false,
+		"tcp":           true,
+		"tcp-closed":    false,
+		"command":       true,
+		"command-fail":  false,
+		"timeout":       false,
+		"invalid":       false,
+	}
+
+	results := RunHealthChecks(context.Background(), t.TempDir(), checks)
+	if len(results) != len(checks) {
+		t.Fatalf("Got %d results, want %d", len(results), len(checks))
+	}
+	for i, r := range results {
+		if r.Name != checks[i].Name {
+			t.Errorf("Result %d is %s, want %s", i, r.Name, checks[i].Name)
+		}
+		if r.Healthy != want[r.Name] {
+			t.Errorf("%s: healthy = %v (%s), want %v", r.Name, r.Healthy, r.Detail, want[r.Name])
+		}
+	}
+	if r := results[7]; r.Detail != "exit status 3: broken" {
+		t.Errorf("Command detail = %q", r.Detail)
+	}
+}
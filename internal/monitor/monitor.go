@@ -2,8 +2,11 @@ package monitor
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/shirou/gopsutil/v4/net"
 	"github.com/shirou/gopsutil/v4/process"
@@ -11,9 +14,9 @@ import (
 
 // ProcessInfo holds basic information about a process.
 type ProcessInfo struct {
-	PID   int32
-	Name  string
-	Ports []int
+	PID   int32  `json:"pid"`
+	Name  string `json:"name"`
+	Ports []int  `json:"ports"`
 }
 
 // GetProjectProcesses finds processes whose CWD matches the project root
@@ -102,3 +105,170 @@ func FindPidByPort(port int) (int32, error) {
 
 	return 0, fmt.Errorf("no process found listening on port %d", port)
 }
+
+// GetProcessEnv returns the environment of a process as KEY=VALUE strings.
+// Reading another user's process usually fails with a permission error.
+func GetProcessEnv(pid int32) ([]string, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("find process %d: %w", pid, err)
+	}
+	env, err := p.Environ()
+	if err != nil {
+		return nil, fmt.Errorf("read environment of %d: %w", pid, err)
+	}
+	return env, nil
+}
+
+// Listener is a socket accepting connections or datagrams on a local port.
+type Listener struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"` // "tcp" or "udp"
+	PID      int32  `json:"pid"`
+}
+
+// GetListeners returns every TCP socket in LISTEN state on the machine and
+// the UDP sockets bound to one of udpPorts, one entry per port, protocol and
+// PID. UDP has no listening state: without the filter the client sockets of
+// every DNS lookup or QUIC connection would be reported.
+func GetListeners(udpPorts []int) ([]Listener, error) {
+	var listeners []Listener
+	seen := make(map[Listener]bool)
+	udp := make(map[int]bool, len(udpPorts))
+	for _, port := range udpPorts {
+		udp[port] = true
+	}
+
+	for _, protocol := range []string{"tcp", "udp"} {
+		connections, err := net.Connections(protocol)
+		if err != nil {
+			return nil, fmt.Errorf("list %s connections: %w", protocol, err)
+		}
+		for _, conn := range connections {
+			if protocol == "tcp" && conn.Status != "LISTEN" || protocol == "udp" && !udp[int(conn.Laddr.Port)] {
+				continue
+			}
+			l := Listener{Port: int(conn.Laddr.Port), Protocol: protocol, PID: conn.Pid}
+			if l.Port == 0 || seen[l] {
+				continue
+			}
+			seen[l] = true
+			listeners = append(listeners, l)
+		}
+	}
+
+	return listeners, nil
+}
+
+// GetProcessName returns the name of a process, or "" if it cannot be read.
+func GetProcessName(pid int32) string {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return ""
+	}
+	name, _ := p.Name()
+	return name
+}
+
+// ProcessDetails describes a process in enough detail to tell where it came from.
+type ProcessDetails struct {
+	PID       int32  `json:"pid"`
+	Name      string `json:"name"`
+	Cmdline   string `json:"cmdline,omitempty"`
+	Cwd       string `json:"cwd,omitempty"`
+	Container string `json:"container,omitempty"` // Container ID or docker-proxy target, if any
+}
+
+// GetProcessDetails returns what can be read about a process. Fields that
+// cannot be read (e.g. another user's cwd) are left empty.
+func GetProcessDetails(pid int32) (ProcessDetails, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return ProcessDetails{}, fmt.Errorf("find process %d: %w", pid, err)
+	}
+
+	d := ProcessDetails{PID: pid}
+	d.Name, _ = p.Name()
+	d.Cmdline, _ = p.Cmdline()
+	d.Cwd, _ = p.Cwd()
+	d.Container = containerOf(pid, d.Name, d.Cmdline)
+	return d, nil
+}
+
+// containerPattern matches container IDs in /proc/<pid>/cgroup lines of
+// docker, containerd, podman and kubernetes.
+var containerPattern = regexp.MustCompile(`(?:docker|containerd|libpod|cri-containerd|crio)[-/]([0-9a-f]{12,64})`)
+
+// containerOf identifies the container a process belongs to. Ports published
+// by Docker are held by docker-proxy, whose arguments name the target.
+func containerOf(pid int32, name, cmdline string) string {
+	if name == "docker-proxy" {
+		fields := strings.Fields(cmdline)
+		var ip, port string
+		for i := 0; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "-container-ip":
+				ip = fields[i+1]
+			case "-container-port":
+				port = fields[i+1]
+			}
+		}
+		if ip != "" {
+			return fmt.Sprintf("docker container at %s:%s", ip, port)
+		}
+		return "docker"
+	}
+
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return ""
+	}
+	if m := containerPattern.FindSubmatch(data); m != nil {
+		return string(m[1][:12])
+	}
+	return ""
+}
+
+// ProcessStats adds resource usage, listening ports and children to the
+// details of a process.
+type ProcessStats struct {
+	ProcessDetails
+	CPUTime  time.Duration `json:"cpu_time_ns"` // User and system time since the process started
+	Started  time.Time     `json:"started"`
+	RSS      uint64        `json:"rss"`
+	Ports    []int         `json:"ports"`
+	Children []ProcessInfo `json:"children"`
+}
+
+// GetProcessStats returns the details, usage and direct children of a
+// process. As in GetProcessDetails, fields that cannot be read are left empty.
+func GetProcessStats(pid int32) (ProcessStats, error) {
+	details, err := GetProcessDetails(pid)
+	if err != nil {
+		return ProcessStats{}, err
+	}
+	stats := ProcessStats{ProcessDetails: details}
+
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return ProcessStats{}, fmt.Errorf("find process %d: %w", pid, err)
+	}
+	if times, err := p.Times(); err == nil {
+		stats.CPUTime = time.Duration((times.User + times.System) * float64(time.Second))
+	}
+	if created, err := p.CreateTime(); err == nil {
+		stats.Started = time.UnixMilli(created)
+	}
+	if mem, err := p.MemoryInfo(); err == nil {
+		stats.RSS = mem.RSS
+	}
+	stats.Ports, _ = GetProcessPorts(pid)
+
+	children, _ := p.Children()
+	for _, child := range children {
+		name, _ := child.Name()
+		ports, _ := GetProcessPorts(child.Pid)
+		stats.Children = append(stats.Children, ProcessInfo{PID: child.Pid, Name: name, Ports: ports})
+	}
+	return stats, nil
+}
@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestGetProcessStats(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	stats, err := GetProcessStats(int32(os.Getpid()))
+	if err != nil {
+		t.Fatalf("GetProcessStats failed: %v", err)
+	}
+	if stats.PID != int32(os.Getpid()) || stats.Cmdline == "" {
+		t.Errorf("Expected details of the test process, got %+v", stats.ProcessDetails)
+	}
+	if stats.RSS == 0 {
+		t.Error("Expected a resident set size")
+	}
+	if stats.Started.IsZero() {
+		t.Error("Expected a start time")
+	}
+
+	found := false
+	for _, child := range stats.Children {
+		if child.PID == int32(cmd.Process.Pid) {
+			found = child.Name == "sleep"
+		}
+	}
+	if !found {
+		t.Errorf("Expected sleep (PID %d) among the children, got %+v", cmd.Process.Pid, stats.Children)
+	}
+
+	if _, err := GetProcessStats(-1); err == nil {
+		t.Error("Expected an error for a missing process")
+	}
+}
+
+func TestGetListeners(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	// The client end of a TCP connection is not listening
+	client, err := net.Dial("tcp", tcp.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+
+	tcpPort := tcp.Addr().(*net.TCPAddr).Port
+	clientPort := client.LocalAddr().(*net.TCPAddr).Port
+	udpPort := udp.LocalAddr().(*net.UDPAddr).Port
+	pid := int32(os.Getpid())
+
+	listening := func(udpPorts []int) map[Listener]bool {
+		listeners, err := GetListeners(udpPorts)
+		if err != nil {
+			t.Fatalf("GetListeners failed: %v", err)
+		}
+		found := make(map[Listener]bool)
+		for _, l := range listeners {
+			if l.PID == pid {
+				found[l] = true
+			}
+		}
+		return found
+	}
+
+	found := listening(nil)
+	if !found[Listener{Port: tcpPort, Protocol: "tcp", PID: pid}] {
+		t.Errorf("Expected %d/tcp among the listeners, got %v", tcpPort, found)
+	}
+	if found[Listener{Port: clientPort, Protocol: "tcp", PID: pid}] {
+		t.Errorf("Connected socket %d/tcp was reported as listening", clientPort)
+	}
+	if found[Listener{Port: udpPort, Protocol: "udp", PID: pid}] {
+		t.Errorf("Undeclared %d/udp was reported as listening", udpPort)
+	}
+
+	if found := listening([]int{udpPort}); !found[Listener{Port: udpPort, Protocol: "udp", PID: pid}] {
+		t.Errorf("Expected declared %d/udp among the listeners, got %v", udpPort, found)
+	}
+}
@@ -0,0 +1,312 @@
+// Package policy checks the working environment against the rules in
+// .trace/policy: which env keys and config values must exist and what they
+// must look like.
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"trace/internal/core"
+)
+
+// FileNames are the names the policy file may have inside .trace, in order of
+// preference. The file is YAML; JSON is accepted as well.
+var FileNames = []string{"policy", "policy.yaml", "policy.yml", "policy.json"}
+
+// Value types a rule can require.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeNumber = "number"
+	TypeBool   = "bool"
+	TypeURL    = "url"
+	TypePort   = "port"
+)
+
+// Policy is the parsed content of .trace/policy.
+//
+//	env:
+//	  DATABASE_URL: {required: true, type: url, schemes: [postgres, postgresql]}
+//	  PORT: {type: int, min: 3000, max: 3999}
+//	files:
+//	  docker-compose.yml:
+//	    required: true
+//	    keys:
+//	      services.db.image: {required: true, pattern: "^postgres:"}
+type Policy struct {
+	Env   map[string]Rule     `yaml:"env"`   // Keys of the tracked .env files
+	Files map[string]FileRule `yaml:"files"` // Tracked JSON/YAML/TOML/INI configs by path
+}
+
+// FileRule constrains a tracked config file and the values of its key paths.
+type FileRule struct {
+	Required bool            `yaml:"required"`
+	Keys     map[string]Rule `yaml:"keys"`
+}
+
+// Rule constrains a single env key or config key path.
+type Rule struct {
+	Required    bool     `yaml:"required"`
+	Type        string   `yaml:"type"`        // One of the Type* constants
+	Pattern     string   `yaml:"pattern"`     // Regular expression the value must match
+	Enum        []string `yaml:"enum"`        // Allowed values
+	Min         *float64 `yaml:"min"`         // Lower bound for int, number and port
+	Max         *float64 `yaml:"max"`         // Upper bound for int, number and port
+	Schemes     []string `yaml:"schemes"`     // Allowed schemes for url
+	Description string   `yaml:"description"` // Shown with violations as a hint
+
+	pattern *regexp.Regexp
+}
+
+// Violation kinds.
+const (
+	Missing = "missing"
+	Invalid = "invalid"
+)
+
+// Violation is a rule the working environment does not satisfy.
+// Messages never include the offending value, which may be a secret.
+type Violation struct {
+	File    string `json:"file,omitempty"` // Empty for env keys
+	Key     string `json:"key,omitempty"`  // Empty when a whole file is missing
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	switch {
+	case v.File == "":
+		return fmt.Sprintf("%s: %s", v.Key, v.Message)
+	case v.Key == "":
+		return fmt.Sprintf("%s: %s", v.File, v.Message)
+	default:
+		return fmt.Sprintf("%s %s: %s", v.File, v.Key, v.Message)
+	}
+}
+
+// Input is the state a policy is evaluated against.
+type Input struct {
+	Env   map[string]string            // Merged keys of all tracked .env files
+	Files map[string]map[string]string // Config path -> key path -> value; absent if the file is missing
+}
+
+// Path returns the policy file of the current project, or "" if there is none.
+func Path() string {
+	dir := core.TraceDir
+	if root, err := core.FindProjectRoot(); err == nil {
+		dir = filepath.Join(root, core.TraceDir)
+	}
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load reads the project's policy file. It returns nil without error if the
+// project has no policy.
+func Load() (*Policy, error) {
+	path := Path()
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses and validates a policy document.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+
+	for key, rule := range p.Env {
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("policy env %s: %w", key, err)
+		}
+		p.Env[key] = rule
+	}
+	for file, fileRule := range p.Files {
+		for key, rule := range fileRule.Keys {
+			if err := rule.compile(); err != nil {
+				return nil, fmt.Errorf("policy %s %s: %w", file, key, err)
+			}
+			fileRule.Keys[key] = rule
+		}
+	}
+	return &p, nil
+}
+
+func (r *Rule) compile() error {
+	switch r.Type {
+	case "", TypeString, TypeInt, TypeNumber, TypeBool, TypeURL, TypePort:
+	default:
+		return fmt.Errorf("unknown type '%s'", r.Type)
+	}
+	if r.Pattern != "" {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		r.pattern = re
+	}
+	return nil
+}
+
+// Evaluate checks in against every rule of the policy. The result is sorted
+// by file and key.
+func (p *Policy) Evaluate(in Input) []Violation {
+	if p == nil {
+		return nil
+	}
+
+	var violations []Violation
+	for key, rule := range p.Env {
+		value, ok := in.Env[key]
+		if !ok {
+			if rule.Required {
+				violations = append(violations, Violation{Key: key, Kind: Missing, Message: rule.hint("is required but not set")})
+			}
+			continue
+		}
+		if problem := rule.check(value); problem != "" {
+			violations = append(violations, Violation{Key: key, Kind: Invalid, Message: rule.hint(problem)})
+		}
+	}
+
+	for file, fileRule := range p.Files {
+		values, ok := in.Files[file]
+		if !ok {
+			if fileRule.Required {
+				violations = append(violations, Violation{File: file, Kind: Missing, Message: "is required but missing or unreadable"})
+			}
+			continue
+		}
+		for key, rule := range fileRule.Keys {
+			value, ok := values[key]
+			if !ok {
+				if rule.Required {
+					violations = append(violations, Violation{File: file, Key: key, Kind: Missing, Message: rule.hint("is required but not set")})
+				}
+				continue
+			}
+			if problem := rule.check(value); problem != "" {
+				violations = append(violations, Violation{File: file, Key: key, Kind: Invalid, Message: rule.hint(problem)})
+			}
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].File != violations[j].File {
+			return violations[i].File < violations[j].File
+		}
+		return violations[i].Key < violations[j].Key
+	})
+	return violations
+}
+
+// check returns a description of why value breaks the rule, or "" if it does not.
+func (r Rule) check(value string) string {
+	if r.Required && strings.TrimSpace(value) == "" {
+		return "is required but empty"
+	}
+
+	switch r.Type {
+	case TypeInt, TypePort:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "must be an integer"
+		}
+		if r.Type == TypePort && (n < 1 || n > 65535) {
+			return "must be a port number (1-65535)"
+		}
+		if problem := r.checkRange(float64(n)); problem != "" {
+			return problem
+		}
+	case TypeNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "must be a number"
+		}
+		if problem := r.checkRange(n); problem != "" {
+			return problem
+		}
+	case TypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be a boolean (true/false)"
+		}
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+			return "must be a URL"
+		}
+		if len(r.Schemes) > 0 && !containsFold(r.Schemes, u.Scheme) {
+			return fmt.Sprintf("must be a %s URL", strings.Join(r.Schemes, " or "))
+		}
+	}
+
+	if len(r.Enum) > 0 && !contains(r.Enum, value) {
+		return fmt.Sprintf("must be one of: %s", strings.Join(r.Enum, ", "))
+	}
+	if r.pattern != nil && !r.pattern.MatchString(value) {
+		return fmt.Sprintf("must match %s", r.Pattern)
+	}
+	return ""
+}
+
+func (r Rule) checkRange(n float64) string {
+	switch {
+	case r.Min != nil && r.Max != nil && (n < *r.Min || n > *r.Max):
+		return fmt.Sprintf("must be between %s and %s", formatBound(*r.Min), formatBound(*r.Max))
+	case r.Min != nil && n < *r.Min:
+		return fmt.Sprintf("must be at least %s", formatBound(*r.Min))
+	case r.Max != nil && n > *r.Max:
+		return fmt.Sprintf("must be at most %s", formatBound(*r.Max))
+	}
+	return ""
+}
+
+// hint appends the rule's description to a message.
+func (r Rule) hint(message string) string {
+	if r.Description == "" {
+		return message
+	}
+	return fmt.Sprintf("%s (%s)", message, r.Description)
+}
+
+func formatBound(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
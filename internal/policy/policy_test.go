@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPolicy = `
+env:
+  DATABASE_URL:
+    required: true
+    type: url
+    schemes: [postgres, postgresql]
+  PORT:
+    type: int
+    min: 3000
+    max: 3999
+  LOG_LEVEL:
+    enum: [debug, info, warn]
+  API_TOKEN:
+    required: true
+    description: ask the team lead
+files:
+  docker-compose.yml:
+    required: true
+    keys:
+      services.db.image:
+        required: true
+        pattern: "^postgres:"
+`
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		in   Input
+		want []string // Violations as File/Key/Kind
+	}{
+		{
+			name: "clean",
+			in: Input{
+				Env: map[string]string{"DATABASE_URL": "postgres://app@localhost/dev", "PORT": "3000", "API_TOKEN": "t"},
+				Files: map[string]map[string]string{
+					"docker-compose.yml": {"services.db.image": "postgres:16"},
+				},
+			},
+		},
+		{
+			name: "invalid values",
+			in: Input{
+				Env: map[string]string{"DATABASE_URL": "mysql://localhost/dev", "PORT": "4000", "LOG_LEVEL": "trace", "API_TOKEN": ""},
+				Files: map[string]map[string]string{
+					"docker-compose.yml": {"services.db.image": "mysql:8"},
+				},
+			},
+			want: []string{
+				"/API_TOKEN/invalid",
+				"/DATABASE_URL/invalid",
+				"/LOG_LEVEL/invalid",
+				"/PORT/invalid",
+				"docker-compose.yml/services.db.image/invalid",
+			},
+		},
+		{
+			name: "missing",
+			in: Input{
+				Env: map[string]string{"PORT": "abc"},
+			},
+			want: []string{
+				"/API_TOKEN/missing",
+				"/DATABASE_URL/missing",
+				"/PORT/invalid",
+				"docker-compose.yml//missing",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := p.Evaluate(tt.in)
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.File+"/"+v.Key+"/"+v.Kind)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViolationHidesValue(t *testing.T) {
+	p, err := Parse([]byte(`{"env": {"SECRET": {"pattern": "^sk_", "description": "use a live key"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations := p.Evaluate(Input{Env: map[string]string{"SECRET": "hunter2"}})
+	if len(violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %d", len(violations))
+	}
+	msg := violations[0].String()
+	if strings.Contains(msg, "hunter2") {
+		t.Errorf("Violation leaks the value: %s", msg)
+	}
+	if !strings.Contains(msg, "use a live key") {
+		t.Errorf("Violation misses the description: %s", msg)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, doc := range []string{
+		"env: {PORT: {type: integer}}",
+		"env: {PORT: {pattern: '('}}",
+		"env: [",
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", doc)
+		}
+	}
+}
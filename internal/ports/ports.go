@@ -0,0 +1,183 @@
+// Package ports compares the ports a project is expected to listen on
+// (config expected_ports) with the sockets actually open on the machine.
+package ports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"trace/internal/config"
+	"trace/internal/core"
+	"trace/internal/monitor"
+)
+
+// Observed is a listening socket and the process that owns it.
+type Observed struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	PID      int32  `json:"pid"`
+	Name     string `json:"name"`
+	Project  bool   `json:"project"` // Owned by a process started from the project directory
+}
+
+// Issue kinds.
+const (
+	Missing    = "missing"    // Nothing listens on an expected port
+	Unexpected = "unexpected" // A project process listens on a port that is not expected
+	Conflict   = "conflict"   // An expected port is held by the wrong process
+)
+
+// Issue is a difference between the expected and the observed ports.
+type Issue struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Kind     string `json:"kind"`
+	PID      int32  `json:"pid,omitempty"`  // Owner of the port, if any
+	Name     string `json:"name,omitempty"` // Name of the owner, if any
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d/%s %s", i.Port, i.Protocol, i.Message)
+}
+
+// Observe lists every listening TCP socket on the machine, plus the UDP
+// sockets bound to the UDP ports in expected, and marks the ones owned by
+// procs, the processes running in the project.
+func Observe(expected []config.ExpectedPort, procs []monitor.ProcessInfo) ([]Observed, error) {
+	var udp []int
+	for _, e := range expected {
+		if e.Proto() == "udp" {
+			udp = append(udp, e.Port)
+		}
+	}
+	listeners, err := monitor.GetListeners(udp)
+	if err != nil {
+		return nil, err
+	}
+
+	project := make(map[int32]string, len(procs))
+	for _, p := range procs {
+		project[p.PID] = p.Name
+	}
+
+	names := make(map[int32]string)
+	observed := make([]Observed, 0, len(listeners))
+	for _, l := range listeners {
+		o := Observed{Port: l.Port, Protocol: l.Protocol, PID: l.PID}
+		if name, ok := project[l.PID]; ok {
+			o.Name, o.Project = name, true
+		} else if l.PID != 0 {
+			if _, ok := names[l.PID]; !ok {
+				names[l.PID] = monitor.GetProcessName(l.PID)
+			}
+			o.Name = names[l.PID]
+		}
+		observed = append(observed, o)
+	}
+
+	sort.Slice(observed, func(i, j int) bool {
+		if observed[i].Port != observed[j].Port {
+			return observed[i].Port < observed[j].Port
+		}
+		return observed[i].Protocol < observed[j].Protocol
+	})
+	return observed, nil
+}
+
+// Bindings returns the ports owned by project processes, sorted by port,
+// in the form recorded in snapshots.
+func Bindings(observed []Observed) []core.PortBinding {
+	var bindings []core.PortBinding
+	seen := make(map[string]bool)
+	for _, o := range observed {
+		b := core.PortBinding{Port: o.Port, Protocol: o.Protocol, Process: o.Name}
+		if !o.Project || seen[b.String()] {
+			continue
+		}
+		seen[b.String()] = true
+		bindings = append(bindings, b)
+	}
+	return bindings
+}
+
+// Check reports expected ports nobody listens on, expected ports held by
+// the wrong process and, if any ports are expected, project ports that are not.
+func Check(expected []config.ExpectedPort, observed []Observed) []Issue {
+	if len(expected) == 0 {
+		return nil
+	}
+
+	var issues []Issue
+	isExpected := make(map[string]bool)
+	for _, e := range expected {
+		isExpected[fmt.Sprintf("%d/%s", e.Port, e.Proto())] = true
+
+		owners := Owners(observed, e.Port, e.Proto())
+		if len(owners) == 0 {
+			issue := Issue{Port: e.Port, Protocol: e.Proto(), Kind: Missing, Message: "is not listening"}
+			if e.Process != "" {
+				issue.Message = fmt.Sprintf("is not listening (expected %s)", e.Process)
+			}
+			issues = append(issues, issue)
+			continue
+		}
+
+		for _, o := range owners {
+			var message string
+			switch {
+			case !o.Project:
+				message = fmt.Sprintf("is held by %s outside the project", describe(o))
+			case e.Process != "" && !strings.EqualFold(o.Name, e.Process):
+				message = fmt.Sprintf("is held by %s, expected %s", describe(o), e.Process)
+			default:
+				continue
+			}
+			issues = append(issues, Issue{Port: e.Port, Protocol: e.Proto(), Kind: Conflict, PID: o.PID, Name: o.Name, Message: message})
+		}
+	}
+
+	reported := make(map[string]bool)
+	for _, o := range observed {
+		key := fmt.Sprintf("%d/%s", o.Port, o.Protocol)
+		if !o.Project || isExpected[key] || reported[key] {
+			continue
+		}
+		reported[key] = true
+		issues = append(issues, Issue{
+			Port:     o.Port,
+			Protocol: o.Protocol,
+			Kind:     Unexpected,
+			PID:      o.PID,
+			Name:     o.Name,
+			Message:  fmt.Sprintf("is opened by %s but not expected", describe(o)),
+		})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Port < issues[j].Port
+	})
+	return issues
+}
+
+// Owners returns the observed sockets bound to a port.
+func Owners(observed []Observed, port int, protocol string) []Observed {
+	var owners []Observed
+	for _, o := range observed {
+		if o.Port == port && o.Protocol == protocol {
+			owners = append(owners, o)
+		}
+	}
+	return owners
+}
+
+func describe(o Observed) string {
+	if o.PID == 0 {
+		return "an unknown process"
+	}
+	if o.Name == "" {
+		return fmt.Sprintf("[%d]", o.PID)
+	}
+	return fmt.Sprintf("%s [%d]", o.Name, o.PID)
+}
@@ -0,0 +1,59 @@
+package ports
+
+import (
+	"reflect"
+	"testing"
+
+	"trace/internal/config"
+	"trace/internal/core"
+)
+
+func TestCheck(t *testing.T) {
+	expected := []config.ExpectedPort{
+		{Port: 8080, Process: "node"},
+		{Port: 5432},
+		{Port: 6379, Process: "redis-server"},
+		{Port: 5353, Protocol: "udp"},
+	}
+	observed := []Observed{
+		{Port: 3000, Protocol: "tcp", PID: 10, Name: "node", Project: true},
+		{Port: 5432, Protocol: "tcp", PID: 20, Name: "postgres"},
+		{Port: 6379, Protocol: "tcp", PID: 30, Name: "python", Project: true},
+		{Port: 8080, Protocol: "tcp", PID: 10, Name: "node", Project: true},
+	}
+
+	var got []string
+	for _, issue := range Check(expected, observed) {
+		got = append(got, issue.String()+" ["+issue.Kind+"]")
+	}
+	want := []string{
+		"3000/tcp is opened by node [10] but not expected [unexpected]",
+		"5353/udp is not listening [missing]",
+		"5432/tcp is held by postgres [20] outside the project [conflict]",
+		"6379/tcp is held by python [30], expected redis-server [conflict]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() =\n%v\nwant\n%v", got, want)
+	}
+
+	if issues := Check(nil, observed); len(issues) != 0 {
+		t.Errorf("Check without expected ports = %v, want none", issues)
+	}
+}
+
+func TestBindings(t *testing.T) {
+	observed := []Observed{
+		{Port: 3000, Protocol: "tcp", PID: 10, Name: "node", Project: true},
+		{Port: 3000, Protocol: "tcp", PID: 11, Name: "node", Project: true},
+		{Port: 5432, Protocol: "tcp", PID: 20, Name: "postgres"},
+		{Port: 5353, Protocol: "udp", PID: 12, Name: "mdns", Project: true},
+	}
+
+	want := []core.PortBinding{
+		{Port: 3000, Protocol: "tcp", Process: "node"},
+		{Port: 5353, Protocol: "udp", Process: "mdns"},
+	}
+	if got := Bindings(observed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Bindings() = %v, want %v", got, want)
+	}
+}
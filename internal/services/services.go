@@ -0,0 +1,200 @@
+// Package services runs the long-lived commands declared under "services"
+// in the config, like a Procfile runner. On Unix, each service runs in its own
+// process group from the project root; its PID and start time are kept in
+// .trace/run/<name>.pid and its stdout and stderr are appended to .trace/run/<name>.log.
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// RunDir is the directory under .trace holding PID and log files.
+const RunDir = "run"
+
+// DefaultStopTimeout is how long Stop waits after SIGTERM before SIGKILL.
+const DefaultStopTimeout = 5 * time.Second
+
+// Service is the state of a declared service.
+type Service struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	PID     int32  `json:"pid,omitempty"` // Set while running
+	Running bool   `json:"running"`
+	Log     string `json:"log"`
+}
+
+// PIDFile returns the path of the PID file of a service.
+func PIDFile(root, name string) string {
+	return filepath.Join(root, ".trace", RunDir, name+".pid")
+}
+
+// LogFile returns the path of the log file of a service.
+func LogFile(root, name string) string {
+	return filepath.Join(root, ".trace", RunDir, name+".log")
+}
+
+// Names returns the names of the declared services, sorted.
+func Names(services map[string]string) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// List returns the state of every declared service, sorted by name.
+func List(root string, services map[string]string) []Service {
+	list := make([]Service, 0, len(services))
+	for _, name := range Names(services) {
+		s := Service{Name: name, Command: services[name], Log: LogFile(root, name)}
+		if pid, ok := Running(root, name); ok {
+			s.PID, s.Running = pid, true
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+// Running returns the PID of a service if it is running. A stale PID file
+// left by a service that exited, or whose PID now belongs to another
+// process, is removed.
+func Running(root, name string) (int32, bool) {
+	pid, _, ok := running(root, name)
+	return int32(pid), ok
+}
+
+func running(root, name string) (int, int64, bool) {
+	data, err := os.ReadFile(PIDFile(root, name))
+	if err != nil {
+		return 0, 0, false
+	}
+	// "<pid> <start time in ms>", or just the PID if the start time was unknown
+	fields := strings.Fields(string(data))
+	var pid int
+	var started int64
+	if len(fields) > 0 {
+		pid, err = strconv.Atoi(fields[0])
+	}
+	if err == nil && len(fields) > 1 {
+		started, err = strconv.ParseInt(fields[1], 10, 64)
+	}
+	if len(fields) == 0 || err != nil || pid <= 0 || !owns(pid, started) {
+		os.Remove(PIDFile(root, name))
+		return 0, 0, false
+	}
+	return pid, started, true
+}
+
+// owns reports whether pid is still the service recorded in a PID file: it
+// is alive, leads its own process group and started at the recorded time
+// (if known). A reused PID fails these checks.
+func owns(pid int, started int64) bool {
+	if !alive(pid) {
+		return false
+	}
+	if pgid, err := GroupOf(int32(pid)); err != nil || int(pgid) != pid {
+		return false
+	}
+	if started == 0 {
+		return true
+	}
+	t, err := startTime(pid)
+	return err == nil && t == started
+}
+
+// startTime returns when a process started, in milliseconds since the epoch.
+func startTime(pid int) (int64, error) {
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return 0, err
+	}
+	return p.CreateTime()
+}
+
+// Start launches a service from root and returns its PID. It fails if the
+// service is already running. The service keeps running after trace exits.
+func Start(root, name, command string) (int32, error) {
+	if pid, ok := Running(root, name); ok {
+		return 0, fmt.Errorf("%s is already running (PID %d)", name, pid)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".trace", RunDir), 0755); err != nil {
+		return 0, err
+	}
+
+	log, err := os.OpenFile(LogFile(root, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("open log: %w", err)
+	}
+	defer log.Close()
+	fmt.Fprintf(log, "==> %s trace: starting %s: %s\n", time.Now().Format(time.RFC3339), name, command)
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = root
+	cmd.Stdout = log
+	cmd.Stderr = log
+	// A process group of its own lets Stop signal the children of the shell
+	// too, and keeps Ctrl+C in the terminal from reaching the service
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("start %s: %w", name, err)
+	}
+	// Reap the service if it exits while trace is still running (e.g. watch)
+	go cmd.Wait()
+
+	pid := cmd.Process.Pid
+	record := strconv.Itoa(pid)
+	if started, err := startTime(pid); err == nil {
+		record += " " + strconv.FormatInt(started, 10)
+	}
+	if err := os.WriteFile(PIDFile(root, name), []byte(record+"\n"), 0644); err != nil {
+		kill(pid)
+		return 0, fmt.Errorf("write PID file: %w", err)
+	}
+	return int32(pid), nil
+}
+
+// Stop sends SIGTERM to the process group of a service and SIGKILL if it
+// is still running after timeout. Stopping a service that is not running
+// is not an error.
+func Stop(root, name string, timeout time.Duration) error {
+	pid, started, ok := running(root, name)
+	if !ok {
+		return nil
+	}
+
+	if err := terminate(pid); err != nil {
+		return fmt.Errorf("stop %s: %w", name, err)
+	}
+	deadline := time.Now().Add(timeout)
+	for owns(pid, started) && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if owns(pid, started) {
+		if err := kill(pid); err != nil {
+			return fmt.Errorf("kill %s: %w", name, err)
+		}
+		for i := 0; i < 20 && owns(pid, started); i++ {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	return os.Remove(PIDFile(root, name))
+}
+
+// Restart stops a service if it is running and starts it again.
+func Restart(root, name, command string, timeout time.Duration) (int32, error) {
+	if err := Stop(root, name, timeout); err != nil {
+		return 0, err
+	}
+	return Start(root, name, command)
+}
@@ -0,0 +1,43 @@
+//go:build !unix
+
+package services
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Without process groups, only the shell of a service is stopped; its
+// children may outlive it.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminate stops pid. There is no graceful signal to send, so it is killed.
+func terminate(pid int) error {
+	return kill(pid)
+}
+
+// kill kills pid.
+func kill(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return nil // Already gone
+	}
+	if err := p.Kill(); err != nil && alive(pid) {
+		return err
+	}
+	return nil
+}
+
+// GroupOf returns pid itself: there are no process groups to match
+// children of a service by.
+func GroupOf(pid int32) (int32, error) {
+	return pid, nil
+}
+
+// alive reports whether pid exists.
+func alive(pid int) bool {
+	exists, err := process.PidExists(int32(pid))
+	return err == nil && exists
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartStop(t *testing.T) {
+	root := t.TempDir()
+
+	pid, err := Start(root, "sleeper", "sleep 30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := Running(root, "sleeper"); !ok || got != pid {
+		t.Fatalf("Running() = %d, %v, want %d, true", got, ok, pid)
+	}
+	if _, err := Start(root, "sleeper", "sleep 30"); err == nil {
+		t.Error("Starting a running service should fail")
+	}
+
+	list := List(root, map[string]string{"sleeper": "sleep 30", "idle": "true"})
+	if len(list) != 2 || list[0].Name != "idle" || list[0].Running || !list[1].Running {
+		t.Errorf("List() = %+v", list)
+	}
+
+	if err := Stop(root, "sleeper", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Running(root, "sleeper"); ok {
+		t.Error("Service still running after Stop")
+	}
+	if _, err := os.Stat(PIDFile(root, "sleeper")); !os.IsNotExist(err) {
+		t.Errorf("PID file left behind: %v", err)
+	}
+	if err := Stop(root, "sleeper", time.Second); err != nil {
+		t.Errorf("Stopping a stopped service: %v", err)
+	}
+}
+
+func TestStopKillsAfterTimeout(t *testing.T) {
+	root := t.TempDir()
+
+	if _, err := Start(root, "stubborn", "trap '' TERM; while :; do sleep 0.1; done"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond) // Let the shell install the trap
+	if err := Stop(root, "stubborn", 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Running(root, "stubborn"); ok {
+		t.Error("Service survived SIGKILL")
+	}
+}
+
+func TestLogs(t *testing.T) {
+	root := t.TempDir()
+
+	if _, err := Start(root, "echo", "echo out; echo err >&2"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := Running(root, "echo"); !ok || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	data, err := os.ReadFile(LogFile(root, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	for _, want := range []string{"starting echo", "out\n", "err\n"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("Log %q does not contain %q", log, want)
+		}
+	}
+}
+
+func TestReusedPID(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".trace", RunDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// An unrelated process that leads its own group, as a service would
+	other := exec.Command("sleep", "30")
+	setProcessGroup(other)
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		other.Process.Kill()
+		other.Wait()
+	}()
+	started, err := startTime(other.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The PID file of a service that ran earlier under the same PID
+	record := fmt.Sprintf("%d %d\n", other.Process.Pid, started-60000)
+	if err := os.WriteFile(PIDFile(root, "web"), []byte(record), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Running(root, "web"); ok {
+		t.Error("A process started at another time was taken for the service")
+	}
+	if _, err := os.Stat(PIDFile(root, "web")); !os.IsNotExist(err) {
+		t.Error("Stale PID file was kept")
+	}
+
+	// A process that does not lead its group is never a service
+	record = fmt.Sprintf("%d\n", os.Getpid())
+	os.WriteFile(PIDFile(root, "web"), []byte(record), 0644)
+	if err := Stop(root, "web", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	record = fmt.Sprintf("%d %d\n", other.Process.Pid, started-60000)
+	os.WriteFile(PIDFile(root, "web"), []byte(record), 0644)
+	if err := Stop(root, "web", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !alive(other.Process.Pid) {
+		t.Error("Stop signalled a process that reused the PID of the service")
+	}
+
+	record = fmt.Sprintf("%d %d\n", other.Process.Pid, started)
+	os.WriteFile(PIDFile(root, "web"), []byte(record), 0644)
+	if pid, ok := Running(root, "web"); !ok || int(pid) != other.Process.Pid {
+		t.Errorf("Running() = %d, %v for a matching start time", pid, ok)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build unix
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminate asks the process group of pid to exit.
+func terminate(pid int) error {
+	return signal(pid, syscall.SIGTERM)
+}
+
+// kill kills the process group of pid.
+func kill(pid int) error {
+	return signal(pid, syscall.SIGKILL)
+}
+
+// GroupOf returns the process group of pid. Services lead their own group.
+func GroupOf(pid int32) (int32, error) {
+	pgid, err := syscall.Getpgid(int(pid))
+	return int32(pgid), err
+}
+
+// signal signals the process group of pid, falling back to pid alone when
+// it does not lead a group.
+func signal(pid int, sig syscall.Signal) error {
+	err := syscall.Kill(-pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		err = syscall.Kill(pid, sig)
+	}
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	if errors.Is(err, syscall.EPERM) {
+		return fmt.Errorf("permission denied")
+	}
+	return err
+}
+
+// alive reports whether pid exists and is not a zombie.
+func alive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true // No procfs (macOS): trust the signal
+	}
+	// The state follows the parenthesized command name
+	if i := strings.LastIndexByte(string(data), ')'); i >= 0 && i+2 < len(data) {
+		return data[i+2] != 'Z'
+	}
+	return true
+}
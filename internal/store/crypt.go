@@ -0,0 +1,256 @@
+package store
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"trace/internal/config"
+)
+
+const (
+	// KeyEnv holds a base64 or hex encoded 32-byte key and overrides the key file.
+	KeyEnv = "TRACE_KEY"
+	// DefaultKeyFile is used when the config does not name a key file.
+	DefaultKeyFile = ".trace/key"
+	keySize        = 32
+)
+
+// encryptedMagic prefixes blobs sealed with AES-256-GCM. The nonce follows it,
+// then the ciphertext of the gzip-compressed content.
+var encryptedMagic = []byte("TRACE-AESGCM-1\n")
+
+// GenerateKey returns a new random encryption key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	return key, nil
+}
+
+// KeyFilePath returns the key file configured in cfg, or the default.
+func KeyFilePath(cfg config.Config) string {
+	if cfg.Encryption.KeyFile != "" {
+		return cfg.Encryption.KeyFile
+	}
+	return DefaultKeyFile
+}
+
+// WriteKeyFile stores a key as base64 in a file readable only by the owner.
+func WriteKeyFile(path string, key []byte) error {
+	data := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		return fmt.Errorf("write key file: %w", err)
+	}
+	return nil
+}
+
+// LoadKey returns the encryption key from TRACE_KEY or the configured key file.
+func LoadKey(cfg config.Config) ([]byte, error) {
+	if env := os.Getenv(KeyEnv); env != "" {
+		key, err := decodeKey(env)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", KeyEnv, err)
+		}
+		return key, nil
+	}
+
+	path := KeyFilePath(cfg)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no encryption key: set %s or create %s", KeyEnv, path)
+		}
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	key, err := decodeKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == hex.EncodedLen(keySize) {
+		if key, err := hex.DecodeString(s); err == nil {
+			return key, nil
+		}
+	}
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, base64 or hex encoded", keySize)
+	}
+	return key, nil
+}
+
+// isEncrypted reports whether blob data was sealed by sealBlob.
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedMagic)
+}
+
+// sealBlob compresses and encrypts content with key.
+func sealBlob(key, content []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	compressed, err := compress(content)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	out := append([]byte{}, encryptedMagic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, compressed, nil), nil
+}
+
+// openBlob decrypts and decompresses data produced by sealBlob.
+func openBlob(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimPrefix(data, encryptedMagic)
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted blob is truncated")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	compressed, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: wrong key or corrupted blob")
+	}
+	return decompress(compressed)
+}
+
+// checkSealed verifies the framing of data produced by sealBlob without a key:
+// the magic, a full nonce and at least the authentication tag must be present.
+func checkSealed(data []byte) error {
+	if !isEncrypted(data) {
+		return fmt.Errorf("missing encryption header")
+	}
+	// AES-GCM as used by sealBlob: 12-byte nonce, 16-byte tag
+	if len(data) < len(encryptedMagic)+12+16 {
+		return fmt.Errorf("encrypted blob is truncated")
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("init cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptBlob opens an encrypted blob using the configured key. While a rekey
+// is in progress, the pending key next to the key file is tried as well.
+func decryptBlob(data []byte) ([]byte, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := LoadKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	content, err := openBlob(key, data)
+	if err == nil {
+		return content, nil
+	}
+
+	if pending, readErr := os.ReadFile(pendingKeyPath(cfg)); readErr == nil {
+		if newKey, decodeErr := decodeKey(string(pending)); decodeErr == nil {
+			if content, openErr := openBlob(newKey, data); openErr == nil {
+				return content, nil
+			}
+		}
+	}
+	return nil, err
+}
+
+// encryptionKey returns the key to seal new blobs with, or nil if encryption is off.
+func encryptionKey() ([]byte, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Encryption.Enabled {
+		return nil, nil
+	}
+	return LoadKey(cfg)
+}
+
+func pendingKeyPath(cfg config.Config) string {
+	return KeyFilePath(cfg) + ".new"
+}
+
+// Rekey re-encrypts every blob with newKey and installs it as the key file.
+// Plaintext blobs (written before encryption was enabled) are encrypted too.
+// The new key is kept next to the old one until every blob is rewritten, so an
+// interrupted rekey can be resumed without losing data.
+func Rekey(newKey []byte) (int, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	pending := pendingKeyPath(cfg)
+	if err := WriteKeyFile(pending, newKey); err != nil {
+		return 0, err
+	}
+
+	hashes, err := ListBlobs()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, hash := range hashes {
+		content, err := LoadBlob(hash)
+		if err != nil {
+			return 0, err
+		}
+		sealed, err := sealBlob(newKey, content)
+		if err != nil {
+			return 0, err
+		}
+		if err := writeLoose(blobPath(hash), sealed); err != nil {
+			return 0, fmt.Errorf("write blob: %w", err)
+		}
+		// Drop any legacy raw copy so only the sealed object remains
+		os.Remove(blobPath(hash))
+	}
+
+	// Rewritten blobs are loose now; fold them back into a pack to drop the old copies
+	packs, err := loadPacks()
+	if err != nil {
+		return len(hashes), err
+	}
+	if len(packs) > 0 {
+		if _, err := Repack(); err != nil {
+			return len(hashes), err
+		}
+	}
+
+	if err := os.Rename(pending, KeyFilePath(cfg)); err != nil {
+		return len(hashes), fmt.Errorf("install key file: %w", err)
+	}
+	return len(hashes), nil
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"trace/internal/config"
+)
+
+func TestEncryptedBlobs(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "trace_crypt_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(tmpDir)
+
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A blob written before encryption was enabled stays plaintext until rekey
+	legacy, _ := SaveBlob([]byte("LEGACY=1\n"))
+
+	key, _ := GenerateKey()
+	WriteKeyFile(DefaultKeyFile, key)
+	cfg := config.DefaultConfig()
+	cfg.Encryption.Enabled = true
+	config.Save(cfg)
+
+	secret := []byte("API_TOKEN=super-secret\n")
+	hash, err := SaveBlob(secret)
+	if err != nil {
+		t.Fatalf("SaveBlob failed: %v", err)
+	}
+
+	raw, _ := readObject(blobObject, hash)
+	if !isEncrypted(raw) || bytes.Contains(raw, []byte("super-secret")) {
+		t.Error("Blob stored in plaintext with encryption enabled")
+	}
+
+	data, err := LoadBlob(hash)
+	if err != nil || !bytes.Equal(data, secret) {
+		t.Fatalf("LoadBlob = %q, %v", data, err)
+	}
+
+	newKey, _ := GenerateKey()
+	if _, err := Rekey(newKey); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+
+	raw, _ = readObject(blobObject, legacy)
+	if !isEncrypted(raw) {
+		t.Error("Rekey did not encrypt the legacy plaintext blob")
+	}
+	if _, err := openBlob(key, mustRead(t, hash)); err == nil {
+		t.Error("Old key still decrypts blob after rekey")
+	}
+	if data, err := LoadBlob(hash); err != nil || !bytes.Equal(data, secret) {
+		t.Errorf("LoadBlob after rekey = %q, %v", data, err)
+	}
+
+	os.Remove(DefaultKeyFile)
+	if _, err := LoadBlob(hash); err == nil || !strings.Contains(err.Error(), KeyEnv) {
+		t.Errorf("Expected missing key error, got %v", err)
+	}
+}
+
+func mustRead(t *testing.T, hash string) []byte {
+	t.Helper()
+	data, err := readObject(blobObject, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
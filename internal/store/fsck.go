@@ -0,0 +1,221 @@
+package store
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"trace/internal/config"
+	"trace/internal/core"
+)
+
+// Issue kinds reported by Fsck.
+const (
+	IssueCorruptCommit  = "corrupt-commit"
+	IssueHashMismatch   = "hash-mismatch"
+	IssueDanglingParent = "dangling-parent"
+	IssueMissingBlob    = "missing-blob"
+	IssueCorruptBlob    = "corrupt-blob"
+	IssueBadRef         = "bad-ref"
+)
+
+// FsckIssue describes a single integrity problem in the object store.
+type FsckIssue struct {
+	Kind   string // One of the Issue* constants
+	Object string // Commit hash, blob hash or ref name
+	Detail string
+}
+
+// FsckReport summarizes an integrity check of the object store.
+type FsckReport struct {
+	Commits int
+	Blobs   int
+	Refs    int
+	Issues  []FsckIssue
+
+	// Unverified counts encrypted blobs whose content could not be checked
+	// because the key is unavailable; UnverifiedReason says why.
+	Unverified       int
+	UnverifiedReason string
+}
+
+// OK returns true if no issues were found.
+func (r FsckReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *FsckReport) add(kind, object, format string, args ...any) {
+	r.Issues = append(r.Issues, FsckIssue{
+		Kind:   kind,
+		Object: object,
+		Detail: fmt.Sprintf(format, args...),
+	})
+}
+
+// Fsck verifies every commit, blob and ref in the store.
+// Commits must parse and hash to their filename, parents and referenced blobs
+// must exist, blobs must hash to their name, and refs must point at commits.
+func Fsck() (FsckReport, error) {
+	var report FsckReport
+
+	if err := fsckBlobs(&report); err != nil {
+		return report, err
+	}
+	if err := fsckCommits(&report); err != nil {
+		return report, err
+	}
+	if err := fsckRefs(&report); err != nil {
+		return report, err
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Kind < report.Issues[j].Kind
+	})
+	return report, nil
+}
+
+func fsckBlobs(report *FsckReport) error {
+	hashes, err := ListBlobs()
+	if err != nil {
+		return err
+	}
+
+	// Without the key every encrypted blob would fail to decrypt, so check for
+	// it once and fall back to verifying only their framing.
+	cfg, err := config.Load()
+	if err == nil {
+		_, err = LoadKey(cfg)
+	}
+	keyErr := err
+
+	for _, hash := range hashes {
+		report.Blobs++
+
+		data, err := readObject(blobObject, hash)
+		if err != nil {
+			report.add(IssueCorruptBlob, hash, "%v", err)
+			continue
+		}
+		if isEncrypted(data) {
+			if keyErr != nil {
+				if err := checkSealed(data); err != nil {
+					report.add(IssueCorruptBlob, hash, "%v", err)
+					continue
+				}
+				report.Unverified++
+				report.UnverifiedReason = keyErr.Error()
+				continue
+			}
+			data, err = decryptBlob(data)
+			if err != nil {
+				report.add(IssueCorruptBlob, hash, "%v", err)
+				continue
+			}
+		}
+		if actual := core.HashContent(data); actual != hash {
+			report.add(IssueHashMismatch, hash, "blob content hashes to %s", core.ShortHash(actual))
+		}
+	}
+	return nil
+}
+
+func fsckCommits(report *FsckReport) error {
+	hashes, err := ListCommits()
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		report.Commits++
+
+		data, err := readObject(commitObject, hash)
+		if err != nil {
+			report.add(IssueCorruptCommit, hash, "%v", err)
+			continue
+		}
+
+		var c core.Commit
+		if err := json.Unmarshal(data, &c); err != nil {
+			report.add(IssueCorruptCommit, hash, "invalid JSON: %v", err)
+			continue
+		}
+
+		if c.Hash != hash {
+			report.add(IssueHashMismatch, hash, "commit records hash %s", core.ShortHash(c.Hash))
+		} else if !c.VerifyHash() {
+			report.add(IssueHashMismatch, hash, "commit content does not match its hash")
+		}
+
+		if c.Parent != "" && !CommitExists(c.Parent) {
+			report.add(IssueDanglingParent, hash, "parent %s does not exist", core.ShortHash(c.Parent))
+		}
+
+		for path, blob := range c.Snapshot.Files {
+			if !BlobExists(blob) {
+				report.add(IssueMissingBlob, hash, "%s references missing blob %s", path, core.ShortHash(blob))
+			}
+		}
+	}
+	return nil
+}
+
+func fsckRefs(report *FsckReport) error {
+	branches, err := core.ListBranches()
+	if err != nil {
+		return fmt.Errorf("list branches: %w", err)
+	}
+	for _, b := range branches {
+		report.Refs++
+		hash, err := core.GetBranch(b)
+		if err != nil {
+			report.add(IssueBadRef, "refs/heads/"+b, "%v", err)
+			continue
+		}
+		checkRefTarget(report, "refs/heads/"+b, hash)
+	}
+
+	autos, err := core.ListAutoRefs()
+	if err != nil {
+		return fmt.Errorf("list auto refs: %w", err)
+	}
+	for _, b := range autos {
+		report.Refs++
+		hash, err := core.GetAuto(b)
+		if err != nil {
+			report.add(IssueBadRef, "refs/"+core.AutoName(b), "%v", err)
+			continue
+		}
+		checkRefTarget(report, "refs/"+core.AutoName(b), hash)
+	}
+
+	report.Refs++
+	branch, err := core.GetCurrentBranch()
+	if err != nil {
+		report.add(IssueBadRef, "HEAD", "%v", err)
+		return nil
+	}
+	if branch != "" {
+		// HEAD may point at a branch without commits yet; the branch itself was checked above.
+		return nil
+	}
+	head, err := core.GetHEAD()
+	if err != nil {
+		report.add(IssueBadRef, "HEAD", "%v", err)
+		return nil
+	}
+	if head != "" {
+		checkRefTarget(report, "HEAD", head)
+	}
+	return nil
+}
+
+func checkRefTarget(report *FsckReport, ref, hash string) {
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != 64 {
+		report.add(IssueBadRef, ref, "invalid commit hash %q", hash)
+		return
+	}
+	if !CommitExists(hash) {
+		report.add(IssueBadRef, ref, "points to missing commit %s", core.ShortHash(hash))
+	}
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"trace/internal/config"
+	"trace/internal/core"
+)
+
+func TestFsck(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "trace_fsck_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(tmpDir)
+
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	blob, _ := SaveBlob([]byte("PORT=3000\n"))
+	c := core.NewCommit("", "initial", core.Snapshot{Files: map[string]string{".env": blob}})
+	SaveCommit(c)
+	core.SetHEAD(c.Hash, "snap", "test")
+
+	report, err := Fsck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("Expected clean store, got %+v", report.Issues)
+	}
+
+	// Corrupt the blob and truncate a second commit
+	os.Remove(filepath.Join(BlobsDir, blob+".gz"))
+	os.WriteFile(filepath.Join(BlobsDir, blob), []byte("PORT=9999\n"), 0644)
+	broken := core.NewCommit(c.Hash, "broken", core.Snapshot{})
+	os.WriteFile(filepath.Join(CommitsDir, broken.Hash+".json"), []byte(`{"hash": "`), 0644)
+
+	report, err = Fsck()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := make(map[string]bool)
+	for _, issue := range report.Issues {
+		kinds[issue.Kind] = true
+	}
+	for _, want := range []string{IssueHashMismatch, IssueCorruptCommit} {
+		if !kinds[want] {
+			t.Errorf("Expected %s issue, got %+v", want, report.Issues)
+		}
+	}
+}
+
+func TestFsckWithoutKey(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "trace_fsck_key_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(tmpDir)
+
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := GenerateKey()
+	WriteKeyFile(DefaultKeyFile, key)
+	cfg := config.DefaultConfig()
+	cfg.Encryption.Enabled = true
+	config.Save(cfg)
+
+	sealed, _ := SaveBlob([]byte("API_TOKEN=secret\n"))
+	truncated, _ := SaveBlob([]byte("DB_PASSWORD=secret\n"))
+	os.Remove(DefaultKeyFile)
+
+	report, err := Fsck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Unverified != 2 {
+		t.Fatalf("Expected 2 unverified blobs and no issues, got %d, %+v", report.Unverified, report.Issues)
+	}
+
+	raw, _ := readObject(blobObject, truncated)
+	os.Remove(filepath.Join(BlobsDir, truncated+".gz"))
+	os.WriteFile(filepath.Join(BlobsDir, truncated), raw[:len(encryptedMagic)+4], 0644)
+
+	report, err = Fsck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Kind != IssueCorruptBlob || report.Issues[0].Object != truncated {
+		t.Errorf("Expected corrupt-blob for %s, got %+v", truncated, report.Issues)
+	}
+	if report.Unverified != 1 {
+		t.Errorf("Expected %s to stay unverified, got %d", sealed, report.Unverified)
+	}
+}
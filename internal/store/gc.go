@@ -0,0 +1,236 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"trace/internal/core"
+)
+
+// GCResult describes the objects removed (or that would be removed) by GC.
+type GCResult struct {
+	Commits []string // Unreachable commit hashes
+	Blobs   []string // Unreachable blob hashes
+	Bytes   int64    // Total size of the unreachable objects
+	Expired int      // Reflog entries older than the cutoff
+	Dropped []string // Reflogs of deleted branches whose entries all expired
+}
+
+// IsEmpty returns true if there is nothing to collect.
+func (r GCResult) IsEmpty() bool {
+	return len(r.Commits) == 0 && len(r.Blobs) == 0 && r.Expired == 0 && len(r.Dropped) == 0
+}
+
+// GC deletes every commit and blob that is not reachable from HEAD, a branch
+// or a reflog entry recorded at or after expire, including the reflogs of
+// deleted branches. Reflog entries older than expire are removed too, and
+// so is the reflog of a deleted branch once none of its entries are left.
+// Unreachable loose objects are removed directly; packs holding unreachable
+// objects are rewritten without them. With dryRun set, nothing is deleted and
+// the result lists what would be removed.
+func GC(dryRun bool, expire time.Time) (GCResult, error) {
+	commits, blobs, err := Reachable(expire)
+	if err != nil {
+		return GCResult{}, err
+	}
+	reachable := map[objectKind]map[string]bool{
+		commitObject: commits,
+		blobObject:   blobs,
+	}
+
+	var result GCResult
+	rewritePacks := false
+
+	for _, kind := range []objectKind{commitObject, blobObject} {
+		hashes, err := listObjects(kind)
+		if err != nil {
+			return result, err
+		}
+		for _, hash := range hashes {
+			if reachable[kind][hash] {
+				continue
+			}
+			for _, path := range looseFiles(kind, hash) {
+				size, err := removeObject(path, dryRun)
+				if err != nil {
+					return result, fmt.Errorf("remove %s %s: %w", kind, core.ShortHash(hash), err)
+				}
+				result.Bytes += size
+			}
+			if size, packed := packedSize(kind, hash); packed {
+				result.Bytes += size
+				rewritePacks = true
+			}
+			if kind == commitObject {
+				result.Commits = append(result.Commits, hash)
+			} else {
+				result.Blobs = append(result.Blobs, hash)
+			}
+		}
+	}
+
+	if rewritePacks && !dryRun {
+		_, err := repack(false, func(kind objectKind, hash string) bool {
+			return reachable[kind][hash]
+		})
+		if err != nil {
+			return result, fmt.Errorf("rewrite packs: %w", err)
+		}
+	}
+
+	if err := expireReflogs(&result, dryRun, expire); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// expireReflogs removes (or counts, with dryRun) the reflog entries older
+// than expire, and the reflogs of deleted branches left without entries.
+func expireReflogs(result *GCResult, dryRun bool, expire time.Time) error {
+	reflogs, err := core.ListReflogs()
+	if err != nil {
+		return fmt.Errorf("list reflogs: %w", err)
+	}
+	for _, ref := range reflogs {
+		entries, err := core.ReadReflog(ref)
+		if err != nil {
+			return fmt.Errorf("read reflog %s: %w", ref, err)
+		}
+		expired := 0
+		for _, e := range entries {
+			if e.Before(expire) {
+				expired++
+			}
+		}
+
+		if !core.RefExists(ref) && expired == len(entries) {
+			if !dryRun {
+				if err := core.DeleteReflog(ref); err != nil {
+					return fmt.Errorf("delete reflog %s: %w", ref, err)
+				}
+			}
+			result.Expired += expired
+			result.Dropped = append(result.Dropped, ref)
+			continue
+		}
+		if !dryRun && expired > 0 {
+			if expired, err = core.ExpireReflog(ref, expire); err != nil {
+				return fmt.Errorf("expire reflog %s: %w", ref, err)
+			}
+		}
+		result.Expired += expired
+	}
+	return nil
+}
+
+// Reachable walks HEAD, every branch and every reflog entry recorded at or
+// after expire back through their parents and returns the set of reachable
+// commit hashes and the blobs they reference.
+func Reachable(expire time.Time) (map[string]bool, map[string]bool, error) {
+	roots, err := rootCommits(expire)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commits := make(map[string]bool)
+	blobs := make(map[string]bool)
+
+	for _, hash := range roots {
+		for hash != "" && !commits[hash] {
+			c, err := LoadCommit(hash)
+			if err != nil {
+				return nil, nil, err
+			}
+			commits[hash] = true
+			for _, blob := range c.Snapshot.Files {
+				blobs[blob] = true
+			}
+			hash = c.Parent
+		}
+	}
+
+	return commits, blobs, nil
+}
+
+// rootCommits returns the commit hashes that HEAD, the branches and the
+// auto-snapshot refs point to, plus every commit that still exists and is
+// recorded in a reflog at or after expire. The reflogs of deleted branches
+// count too: they are how a mistaken `branch -d` is undone.
+func rootCommits(expire time.Time) ([]string, error) {
+	var roots []string
+
+	head, err := core.GetHEAD()
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD: %w", err)
+	}
+	if head != "" {
+		roots = append(roots, head)
+	}
+
+	branches, err := core.ListBranches()
+	if err != nil {
+		return nil, fmt.Errorf("list branches: %w", err)
+	}
+	for _, b := range branches {
+		hash, err := core.GetBranch(b)
+		if err != nil {
+			return nil, fmt.Errorf("read branch %s: %w", b, err)
+		}
+		if hash != "" {
+			roots = append(roots, hash)
+		}
+	}
+
+	autos, err := core.ListAutoRefs()
+	if err != nil {
+		return nil, fmt.Errorf("list auto refs: %w", err)
+	}
+	for _, b := range autos {
+		hash, err := core.GetAuto(b)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", core.AutoName(b), err)
+		}
+		if hash != "" {
+			roots = append(roots, hash)
+		}
+	}
+
+	reflogs, err := core.ListReflogs()
+	if err != nil {
+		return nil, fmt.Errorf("list reflogs: %w", err)
+	}
+	for _, ref := range reflogs {
+		entries, err := core.ReadReflog(ref)
+		if err != nil {
+			return nil, fmt.Errorf("read reflog %s: %w", ref, err)
+		}
+		for _, e := range entries {
+			if e.Before(expire) {
+				continue
+			}
+			for _, hash := range []string{e.Old, e.New} {
+				// Reflogs may mention commits pruned before they were recorded
+				if hash != "" && CommitExists(hash) {
+					roots = append(roots, hash)
+				}
+			}
+		}
+	}
+
+	return roots, nil
+}
+
+// removeObject deletes the object at path (unless dryRun) and returns its size.
+func removeObject(path string, dryRun bool) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !dryRun {
+		if err := os.Remove(path); err != nil {
+			return 0, err
+		}
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"trace/internal/core"
+)
+
+func TestGC(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "trace_gc_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(tmpDir)
+
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	keptBlob, _ := SaveBlob([]byte("PORT=3000\n"))
+	staleBlob, _ := SaveBlob([]byte("PORT=4000\n"))
+	resetBlob, _ := SaveBlob([]byte("PORT=5000\n"))
+
+	kept := core.NewCommit("", "kept", core.Snapshot{Files: map[string]string{".env": keptBlob}})
+	SaveCommit(kept)
+	core.SetHEAD(kept.Hash, "snap", "test")
+
+	// A commit HEAD moved away from: only its reflog entries keep it
+	reset := core.NewCommit(kept.Hash, "reset", core.Snapshot{Files: map[string]string{".env": resetBlob}})
+	SaveCommit(reset)
+	core.SetHEAD(reset.Hash, "snap", "test")
+	core.SetHEAD(kept.Hash, "reset", "test")
+
+	// An auto-snapshot on top of HEAD
+	auto := core.NewCommit(kept.Hash, "auto", core.Snapshot{Files: map[string]string{".env": keptBlob}})
+	SaveCommit(auto)
+	core.SetAuto("main", auto.Hash, "auto")
+
+	// A commit on a branch that was deleted
+	orphan := core.NewCommit(kept.Hash, "orphan", core.Snapshot{Files: map[string]string{".env": staleBlob}})
+	SaveCommit(orphan)
+	core.SetBranch("feature", orphan.Hash, "branch", "created")
+	if err := core.DeleteBranch("feature"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reflog of the deleted branch keeps its commits until it expires
+	result, err := GC(false, time.Time{})
+	if err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	if !result.IsEmpty() {
+		t.Errorf("Expected nothing to collect while reflog entries are recent, got %+v", result)
+	}
+	if !CommitExists(orphan.Hash) {
+		t.Error("Commit of the deleted branch was collected before its reflog expired")
+	}
+
+	expire := time.Now().Add(time.Minute)
+	result, err = GC(true, expire)
+	if err != nil {
+		t.Fatalf("GC dry run failed: %v", err)
+	}
+	if len(result.Commits) != 2 {
+		t.Errorf("Expected the orphan and reset commits to be collected, got %v", result.Commits)
+	}
+	if len(result.Blobs) != 2 {
+		t.Errorf("Expected the stale and reset blobs to be collected, got %v", result.Blobs)
+	}
+	if len(result.Dropped) != 1 || result.Dropped[0] != core.BranchRef("feature") {
+		t.Errorf("Expected the reflog of the deleted branch to be dropped, got %v", result.Dropped)
+	}
+	if !CommitExists(orphan.Hash) {
+		t.Error("Dry run deleted the orphan commit")
+	}
+	if entries, _ := core.ReadReflog("feature"); len(entries) == 0 {
+		t.Error("Dry run deleted the reflog of the deleted branch")
+	}
+
+	// Expiring every reflog entry leaves only what the refs point to
+	result, err = GC(false, expire)
+	if err != nil {
+		t.Fatalf("GC with expiry failed: %v", err)
+	}
+	if CommitExists(orphan.Hash) || CommitExists(reset.Hash) {
+		t.Error("Commits only kept by expired reflog entries still exist after GC")
+	}
+	if _, err := LoadBlob(staleBlob); err == nil {
+		t.Error("Stale blob still exists after GC")
+	}
+	if result.Expired == 0 {
+		t.Error("Expected reflog entries to expire")
+	}
+	if entries, _ := core.ReadReflog("feature"); len(entries) != 0 {
+		t.Errorf("Reflog of the deleted branch still exists after GC: %v", entries)
+	}
+	if entries, _ := core.ReadReflog(core.HeadRef); len(entries) != 0 {
+		t.Errorf("Expected the HEAD reflog to be empty, got %v", entries)
+	}
+	if !CommitExists(kept.Hash) || !CommitExists(auto.Hash) {
+		t.Error("Commit pointed to by a ref was deleted")
+	}
+	if _, err := LoadBlob(keptBlob); err != nil {
+		t.Error("Reachable blob was deleted")
+	}
+}
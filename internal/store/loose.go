@@ -0,0 +1,216 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// objectKind distinguishes the two kinds of objects in the store.
+type objectKind int
+
+const (
+	commitObject objectKind = iota
+	blobObject
+)
+
+func (k objectKind) String() string {
+	if k == commitObject {
+		return "commit"
+	}
+	return "blob"
+}
+
+// compressedSuffix marks loose objects written in compressed form.
+// Objects without it are legacy raw objects and are still readable.
+const compressedSuffix = ".gz"
+
+func commitPath(hash string) string {
+	return filepath.Join(CommitsDir, hash+".json")
+}
+
+func blobPath(hash string) string {
+	return filepath.Join(BlobsDir, hash)
+}
+
+func loosePath(kind objectKind, hash string) string {
+	if kind == commitObject {
+		return commitPath(hash)
+	}
+	return blobPath(hash)
+}
+
+// looseFiles returns the on-disk files (compressed and/or raw) holding an object.
+func looseFiles(kind objectKind, hash string) []string {
+	base := loosePath(kind, hash)
+	var files []string
+	for _, path := range []string{base + compressedSuffix, base} {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// readObject returns the uncompressed content of an object, looking at
+// compressed loose objects, then raw loose objects, then packs.
+func readObject(kind objectKind, hash string) ([]byte, error) {
+	base := loosePath(kind, hash)
+
+	data, err := readCompressed(base + compressedSuffix)
+	if err == nil {
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	data, err = os.ReadFile(base)
+	if err == nil {
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	data, found, err := readPacked(kind, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s %s: %w", kind, hash, fs.ErrNotExist)
+	}
+	return data, nil
+}
+
+// objectExists checks for an object in loose or packed form.
+func objectExists(kind objectKind, hash string) bool {
+	if len(looseFiles(kind, hash)) > 0 {
+		return true
+	}
+	_, found := findPacked(kind, hash)
+	return found
+}
+
+// listObjects returns the sorted hashes of every object of a kind.
+func listObjects(kind objectKind) ([]string, error) {
+	dir, suffix := BlobsDir, ""
+	if kind == commitObject {
+		dir, suffix = CommitsDir, ".json"
+	}
+
+	seen := make(map[string]bool)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read %ss: %w", kind, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), compressedSuffix)
+		if !strings.HasSuffix(name, suffix) || strings.HasPrefix(name, ".") {
+			continue
+		}
+		seen[strings.TrimSuffix(name, suffix)] = true
+	}
+
+	packs, err := loadPacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		for hash := range p.index.entries(kind) {
+			seen[hash] = true
+		}
+	}
+
+	hashes := make([]string, 0, len(seen))
+	for hash := range seen {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes, nil
+}
+
+// ListCommits returns the hashes of all stored commits.
+func ListCommits() ([]string, error) {
+	return listObjects(commitObject)
+}
+
+// ListBlobs returns the hashes of all stored blobs.
+func ListBlobs() ([]string, error) {
+	return listObjects(blobObject)
+}
+
+// writeLoose compresses data and writes it next to base with the compressed suffix.
+func writeLoose(base string, data []byte) error {
+	compressed, err := compress(data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(base+compressedSuffix, compressed)
+}
+
+func readCompressed(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(data)
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompress: %w", err)
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompress: %w", err)
+	}
+	return out, nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place,
+// so a crash or full disk never leaves a truncated object behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 
 	"trace/internal/core"
 )
@@ -15,7 +14,8 @@ const (
 	CommitsDir = ".trace/objects/commits"
 	BlobsDir   = ".trace/objects/blobs"
 	ConfigFile = ".trace/config.json"
-	LogsDir    = ".trace/logs"
+	LogsDir    = core.LogsDir
+	PackDir    = ".trace/objects/pack"
 )
 
 // Init creates the .trace directory structure.
@@ -25,6 +25,7 @@ func Init() error {
 		ObjectsDir,
 		CommitsDir,
 		BlobsDir,
+		PackDir,
 		core.HeadsDir,
 		LogsDir,
 	}
@@ -36,25 +37,23 @@ func Init() error {
 	return core.InitRefs()
 }
 
-// SaveCommit stores a commit object and returns its hash.
+// SaveCommit stores a commit object as a compressed loose object.
 func SaveCommit(c *core.Commit) error {
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal commit: %w", err)
 	}
 
-	path := filepath.Join(CommitsDir, c.Hash+".json")
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := writeLoose(commitPath(c.Hash), data); err != nil {
 		return fmt.Errorf("write commit: %w", err)
 	}
 
 	return nil
 }
 
-// LoadCommit reads a commit by its hash.
+// LoadCommit reads a commit by its hash from loose objects or packs.
 func LoadCommit(hash string) (*core.Commit, error) {
-	path := filepath.Join(CommitsDir, hash+".json")
-	data, err := os.ReadFile(path)
+	data, err := readObject(commitObject, hash)
 	if err != nil {
 		return nil, fmt.Errorf("read commit %s: %w", core.ShortHash(hash), err)
 	}
@@ -68,80 +67,61 @@ func LoadCommit(hash string) (*core.Commit, error) {
 }
 
 // SaveBlob stores file content and returns its hash.
+// The hash is always of the plaintext; if encryption is enabled in the config
+// the stored object is sealed with the repository key.
 func SaveBlob(content []byte) (string, error) {
 	hash := core.HashContent(content)
-	path := filepath.Join(BlobsDir, hash)
 
 	// Skip if blob already exists (content-addressable)
-	if _, err := os.Stat(path); err == nil {
+	if BlobExists(hash) {
 		return hash, nil
 	}
 
-	if err := os.WriteFile(path, content, 0644); err != nil {
+	key, err := encryptionKey()
+	if err != nil {
+		return "", fmt.Errorf("encryption key: %w", err)
+	}
+
+	data := content
+	if key != nil {
+		data, err = sealBlob(key, content)
+		if err != nil {
+			return "", fmt.Errorf("encrypt blob: %w", err)
+		}
+	}
+
+	if err := writeLoose(blobPath(hash), data); err != nil {
 		return "", fmt.Errorf("write blob: %w", err)
 	}
 
 	return hash, nil
 }
 
-// LoadBlob reads blob content by its hash.
+// LoadBlob reads blob content by its hash from loose objects or packs,
+// decrypting it if it was stored encrypted.
 func LoadBlob(hash string) ([]byte, error) {
-	path := filepath.Join(BlobsDir, hash)
-	data, err := os.ReadFile(path)
+	data, err := readObject(blobObject, hash)
 	if err != nil {
 		return nil, fmt.Errorf("read blob %s: %w", core.ShortHash(hash), err)
 	}
+
+	if isEncrypted(data) {
+		data, err = decryptBlob(data)
+		if err != nil {
+			return nil, fmt.Errorf("read blob %s: %w", core.ShortHash(hash), err)
+		}
+	}
 	return data, nil
 }
 
 // CommitExists checks if a commit with the given hash exists.
 func CommitExists(hash string) bool {
-	path := filepath.Join(CommitsDir, hash+".json")
-	_, err := os.Stat(path)
-	return err == nil
+	return objectExists(commitObject, hash)
 }
 
-// ResolveCommit resolves a partial hash or branch name to a full commit hash.
-func ResolveCommit(ref string) (string, error) {
-	// Try as exact commit hash first
-	if CommitExists(ref) {
-		return ref, nil
-	}
-
-	// Try as branch name
-	hash, err := core.GetBranch(ref)
-	if err == nil && hash != "" {
-		return hash, nil
-	}
-
-	// Try as HEAD
-	if ref == "HEAD" {
-		return core.GetHEAD()
-	}
-
-	// Try as partial hash
-	entries, err := os.ReadDir(CommitsDir)
-	if err != nil {
-		return "", fmt.Errorf("read commits: %w", err)
-	}
-
-	var matches []string
-	for _, e := range entries {
-		name := e.Name()
-		hash := name[:len(name)-5] // Remove .json
-		if len(ref) >= 4 && len(hash) >= len(ref) && hash[:len(ref)] == ref {
-			matches = append(matches, hash)
-		}
-	}
-
-	if len(matches) == 0 {
-		return "", fmt.Errorf("commit not found: %s", ref)
-	}
-	if len(matches) > 1 {
-		return "", fmt.Errorf("ambiguous commit reference: %s", ref)
-	}
-
-	return matches[0], nil
+// BlobExists checks if a blob with the given hash exists.
+func BlobExists(hash string) bool {
+	return objectExists(blobObject, hash)
 }
 
 // GetCommitHistory returns commits from the given hash back to the root.
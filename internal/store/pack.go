@@ -0,0 +1,290 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"trace/internal/core"
+)
+
+// A pack stores many compressed objects in a single file (pack-<hash>.pack)
+// with a JSON index (pack-<hash>.idx) mapping each hash to its byte range.
+
+type packEntry struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+type packIndex struct {
+	Commits map[string]packEntry `json:"commits"`
+	Blobs   map[string]packEntry `json:"blobs"`
+}
+
+func (idx packIndex) entries(kind objectKind) map[string]packEntry {
+	if kind == commitObject {
+		return idx.Commits
+	}
+	return idx.Blobs
+}
+
+type pack struct {
+	path  string // Path to the .pack file
+	index packIndex
+}
+
+type cachedIndex struct {
+	modTime time.Time
+	index   packIndex
+}
+
+var (
+	indexCacheMu sync.Mutex
+	indexCache   = make(map[string]cachedIndex)
+)
+
+// RepackResult summarizes a repack.
+type RepackResult struct {
+	Commits int
+	Blobs   int
+	Before  int64 // Bytes used by the objects before repacking
+	After   int64 // Bytes used by the new pack and index
+}
+
+// Repack consolidates every loose and packed object into a single packfile
+// and removes the loose objects and old packs it replaces.
+func Repack() (RepackResult, error) {
+	return repack(true, nil)
+}
+
+// repack writes a new pack containing the selected objects and removes the
+// old packs. Loose objects are only moved into the pack if includeLoose is set;
+// keep (if non-nil) filters which objects survive.
+func repack(includeLoose bool, keep func(objectKind, string) bool) (RepackResult, error) {
+	var result RepackResult
+
+	oldPacks, err := loadPacks()
+	if err != nil {
+		return result, err
+	}
+
+	var buf bytes.Buffer
+	index := packIndex{
+		Commits: make(map[string]packEntry),
+		Blobs:   make(map[string]packEntry),
+	}
+	var looseToRemove []string
+
+	for _, kind := range []objectKind{commitObject, blobObject} {
+		hashes, err := listObjects(kind)
+		if err != nil {
+			return result, err
+		}
+		for _, hash := range hashes {
+			loose := looseFiles(kind, hash)
+			if len(loose) > 0 && !includeLoose {
+				continue
+			}
+			if keep != nil && !keep(kind, hash) {
+				continue
+			}
+
+			data, err := readObject(kind, hash)
+			if err != nil {
+				return result, fmt.Errorf("read %s %s: %w", kind, core.ShortHash(hash), err)
+			}
+			compressed, err := compress(data)
+			if err != nil {
+				return result, err
+			}
+
+			index.entries(kind)[hash] = packEntry{
+				Offset: int64(buf.Len()),
+				Length: int64(len(compressed)),
+			}
+			buf.Write(compressed)
+
+			if kind == commitObject {
+				result.Commits++
+			} else {
+				result.Blobs++
+			}
+			looseToRemove = append(looseToRemove, loose...)
+		}
+	}
+
+	var newPack string
+	if buf.Len() > 0 {
+		newPack, err = writePack(buf.Bytes(), index)
+		if err != nil {
+			return result, err
+		}
+		result.After = fileSize(newPack) + fileSize(indexPath(newPack))
+	}
+
+	for _, p := range oldPacks {
+		if p.path == newPack {
+			continue
+		}
+		result.Before += fileSize(p.path) + fileSize(indexPath(p.path))
+		// Remove the index first so a partial removal never leaves an index without its pack
+		if err := os.Remove(indexPath(p.path)); err != nil {
+			return result, fmt.Errorf("remove pack: %w", err)
+		}
+		if err := os.Remove(p.path); err != nil {
+			return result, fmt.Errorf("remove pack: %w", err)
+		}
+	}
+
+	for _, path := range looseToRemove {
+		result.Before += fileSize(path)
+		if err := os.Remove(path); err != nil {
+			return result, fmt.Errorf("remove loose object: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// writePack stores pack data and its index, returning the path of the pack file.
+func writePack(data []byte, index packIndex) (string, error) {
+	if err := os.MkdirAll(PackDir, 0755); err != nil {
+		return "", fmt.Errorf("create %s: %w", PackDir, err)
+	}
+
+	idxData, err := json.Marshal(index)
+	if err != nil {
+		return "", fmt.Errorf("marshal pack index: %w", err)
+	}
+
+	path := filepath.Join(PackDir, "pack-"+core.HashContent(data)+".pack")
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", fmt.Errorf("write pack: %w", err)
+	}
+	// The index is written last: a pack only becomes visible once its index exists
+	if err := writeFileAtomic(indexPath(path), idxData); err != nil {
+		return "", fmt.Errorf("write pack index: %w", err)
+	}
+	return path, nil
+}
+
+// loadPacks returns every pack with a readable index.
+func loadPacks() ([]*pack, error) {
+	matches, err := filepath.Glob(filepath.Join(PackDir, "pack-*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	var packs []*pack
+	for _, idxPath := range matches {
+		index, err := loadIndex(idxPath)
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, &pack{
+			path:  strings.TrimSuffix(idxPath, ".idx") + ".pack",
+			index: index,
+		})
+	}
+	return packs, nil
+}
+
+// loadIndex reads a pack index, reusing the cached copy if the file is unchanged.
+func loadIndex(path string) (packIndex, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return packIndex{}, fmt.Errorf("read pack index: %w", err)
+	}
+
+	abs, _ := filepath.Abs(path)
+
+	indexCacheMu.Lock()
+	cached, ok := indexCache[abs]
+	indexCacheMu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.index, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return packIndex{}, fmt.Errorf("read pack index: %w", err)
+	}
+	var index packIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return packIndex{}, fmt.Errorf("parse pack index %s: %w", filepath.Base(path), err)
+	}
+
+	indexCacheMu.Lock()
+	indexCache[abs] = cachedIndex{modTime: info.ModTime(), index: index}
+	indexCacheMu.Unlock()
+
+	return index, nil
+}
+
+// findPacked locates the pack holding an object.
+func findPacked(kind objectKind, hash string) (*pack, bool) {
+	packs, err := loadPacks()
+	if err != nil {
+		return nil, false
+	}
+	for _, p := range packs {
+		if _, ok := p.index.entries(kind)[hash]; ok {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// readPacked reads and decompresses an object from the pack holding it.
+func readPacked(kind objectKind, hash string) ([]byte, bool, error) {
+	p, found := findPacked(kind, hash)
+	if !found {
+		return nil, false, nil
+	}
+	entry := p.index.entries(kind)[hash]
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil, true, fmt.Errorf("open pack: %w", err)
+	}
+	defer f.Close()
+
+	data := make([]byte, entry.Length)
+	if _, err := f.ReadAt(data, entry.Offset); err != nil {
+		return nil, true, fmt.Errorf("read pack %s: %w", filepath.Base(p.path), err)
+	}
+
+	out, err := decompress(data)
+	if err != nil {
+		return nil, true, err
+	}
+	return out, true, nil
+}
+
+// packedSize returns the compressed size of an object inside a pack.
+func packedSize(kind objectKind, hash string) (int64, bool) {
+	p, found := findPacked(kind, hash)
+	if !found {
+		return 0, false
+	}
+	return p.index.entries(kind)[hash].Length, true
+}
+
+func indexPath(packPath string) string {
+	return strings.TrimSuffix(packPath, ".pack") + ".idx"
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
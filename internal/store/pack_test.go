@@ -0,0 +1,71 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"trace/internal/core"
+)
+
+func TestRepack(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "trace_pack_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(tmpDir)
+
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	blob, _ := SaveBlob([]byte("DATABASE_URL=postgres://localhost/dev\n"))
+	first := core.NewCommit("", "first", core.Snapshot{Files: map[string]string{".env": blob}})
+	SaveCommit(first)
+	second := core.NewCommit(first.Hash, "second", core.Snapshot{Files: map[string]string{".env": blob}})
+	SaveCommit(second)
+	core.SetHEAD(second.Hash, "snap", "test")
+
+	result, err := Repack()
+	if err != nil {
+		t.Fatalf("Repack failed: %v", err)
+	}
+	if result.Commits != 2 || result.Blobs != 1 {
+		t.Errorf("Expected 2 commits and 1 blob packed, got %+v", result)
+	}
+	if len(looseFiles(commitObject, first.Hash)) != 0 {
+		t.Error("Loose commit still present after repack")
+	}
+
+	c, err := LoadCommit(second.Hash)
+	if err != nil || c.Parent != first.Hash {
+		t.Fatalf("LoadCommit from pack failed: %v", err)
+	}
+	data, err := LoadBlob(blob)
+	if err != nil || string(data) != "DATABASE_URL=postgres://localhost/dev\n" {
+		t.Fatalf("LoadBlob from pack failed: %v", err)
+	}
+
+	hash, err := ResolveCommit(first.Hash[:8])
+	if err != nil || hash != first.Hash {
+		t.Errorf("ResolveCommit prefix in pack = %s, %v", hash, err)
+	}
+
+	// Dropping HEAD back to the first commit (and forgetting the reflog)
+	// leaves the second one unreachable inside the pack
+	core.SetHEAD(first.Hash, "reset", "test")
+	os.RemoveAll(LogsDir)
+	if _, err := GC(false, time.Time{}); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	if CommitExists(second.Hash) {
+		t.Error("Packed unreachable commit survived GC")
+	}
+	if !CommitExists(first.Hash) || !BlobExists(blob) {
+		t.Error("GC removed reachable packed objects")
+	}
+}
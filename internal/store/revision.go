@@ -0,0 +1,287 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"trace/internal/core"
+)
+
+// revisionPattern splits a revision into its base name, an optional @{...}
+// selector and trailing ~N / ^N parent steps.
+var revisionPattern = regexp.MustCompile(`^([^~^@]*|@)(?:@\{([^}]*)\})?((?:[~^]\d*)*)$`)
+
+var relativeDatePattern = regexp.MustCompile(`^(\d+)[ .](second|minute|hour|day|week|month|year)s?[ .]ago$`)
+
+// ResolveCommit resolves a revision to a full commit hash. Supported forms:
+//
+//	<hash>, <hash prefix>, <branch>, HEAD (or @)
+//	<ref>@{n}        the n-th previous value of HEAD or a branch, from its reflog
+//	<rev>@{<date>}   the last commit at or before date (yesterday, 2 days ago, 2026-10-01)
+//	<rev>~N, <rev>^  the N-th parent / the parent
+func ResolveCommit(rev string) (string, error) {
+	m := revisionPattern.FindStringSubmatch(rev)
+	if m == nil || rev == "" {
+		return "", fmt.Errorf("invalid revision: %s", rev)
+	}
+	base, selector, steps := m[1], m[2], m[3]
+	if base == "" || base == "@" {
+		base = core.HeadRef
+	}
+
+	var hash string
+	var err error
+
+	if n, convErr := strconv.Atoi(selector); convErr == nil && selector != "" {
+		hash, err = resolveReflog(base, n)
+	} else {
+		hash, err = resolveName(base)
+		if err == nil && selector != "" {
+			hash, err = resolveDate(hash, selector)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return walkParents(hash, rev, steps)
+}
+
+// IsRange reports whether spec is a commit range of the form A..B.
+func IsRange(spec string) bool {
+	return strings.Contains(spec, "..")
+}
+
+// ResolveRange resolves a range "A..B" to the hashes of A and B.
+// Either side may be omitted and defaults to HEAD.
+func ResolveRange(spec string) (string, string, error) {
+	from, to, ok := strings.Cut(spec, "..")
+	if !ok {
+		return "", "", fmt.Errorf("invalid range: %s", spec)
+	}
+	if from == "" {
+		from = core.HeadRef
+	}
+	if to == "" {
+		to = core.HeadRef
+	}
+
+	fromHash, err := ResolveCommit(from)
+	if err != nil {
+		return "", "", err
+	}
+	toHash, err := ResolveCommit(to)
+	if err != nil {
+		return "", "", err
+	}
+	return fromHash, toHash, nil
+}
+
+// GetCommitRange returns the commits reachable from to but not from from, newest first.
+func GetCommitRange(from, to string) ([]*core.Commit, error) {
+	exclude := make(map[string]bool)
+	if from != "" {
+		history, err := GetCommitHistory(from)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range history {
+			exclude[c.Hash] = true
+		}
+	}
+
+	var commits []*core.Commit
+	hash := to
+	for hash != "" && !exclude[hash] {
+		c, err := LoadCommit(hash)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, c)
+		hash = c.Parent
+	}
+	return commits, nil
+}
+
+// resolveName resolves an exact hash, branch name, HEAD, auto/<branch> or
+// unique hash prefix.
+func resolveName(ref string) (string, error) {
+	// Try as exact commit hash first
+	if CommitExists(ref) {
+		return ref, nil
+	}
+
+	// Try as auto-snapshot ref
+	if branch, ok := strings.CutPrefix(ref, "auto/"); ok {
+		hash, err := core.GetAuto(branch)
+		if err != nil {
+			return "", err
+		}
+		if hash != "" {
+			return hash, nil
+		}
+	}
+
+	// Try as branch name
+	hash, err := core.GetBranch(ref)
+	if err == nil && hash != "" {
+		return hash, nil
+	}
+
+	// Try as HEAD
+	if ref == core.HeadRef {
+		head, err := core.GetHEAD()
+		if err != nil {
+			return "", err
+		}
+		if head == "" {
+			return "", fmt.Errorf("HEAD has no commits yet")
+		}
+		return head, nil
+	}
+
+	// Try as partial hash
+	hashes, err := ListCommits()
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, hash := range hashes {
+		if len(ref) >= 4 && len(hash) >= len(ref) && hash[:len(ref)] == ref {
+			matches = append(matches, hash)
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("commit not found: %s", ref)
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("ambiguous commit reference: %s", ref)
+	}
+
+	return matches[0], nil
+}
+
+// resolveReflog returns the commit a ref pointed to n moves ago.
+func resolveReflog(ref string, n int) (string, error) {
+	entries, err := core.ReadReflog(ref)
+	if err != nil {
+		return "", fmt.Errorf("read reflog %s: %w", ref, err)
+	}
+	if n >= len(entries) {
+		return "", fmt.Errorf("reflog for %s has only %d entries", ref, len(entries))
+	}
+
+	hash := entries[n].New
+	if hash == "" {
+		return "", fmt.Errorf("%s@{%d} records a deletion; try %s@{%d}", ref, n, ref, n+1)
+	}
+	return hash, nil
+}
+
+// resolveDate returns the newest commit in the history of hash whose
+// timestamp is at or before the given date expression.
+func resolveDate(hash, expr string) (string, error) {
+	at, err := ParseDate(expr, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	for hash != "" {
+		c, err := LoadCommit(hash)
+		if err != nil {
+			return "", err
+		}
+		t, err := time.Parse(time.RFC3339, c.Timestamp)
+		if err == nil && !t.After(at) {
+			return c.Hash, nil
+		}
+		hash = c.Parent
+	}
+	return "", fmt.Errorf("no commit at or before %s", at.Format("2006-01-02 15:04:05"))
+}
+
+// walkParents applies ~N and ^N steps to hash.
+func walkParents(hash, rev, steps string) (string, error) {
+	for steps != "" {
+		op := steps[0]
+		steps = steps[1:]
+
+		digits := len(steps) - len(strings.TrimLeft(steps, "0123456789"))
+		n := 1
+		if digits > 0 {
+			n, _ = strconv.Atoi(steps[:digits])
+			steps = steps[digits:]
+		}
+
+		// Commits have a single parent, so ^N only allows ^0 (itself) and ^1
+		if op == '^' {
+			if n > 1 {
+				return "", fmt.Errorf("invalid revision %s: commits have only one parent", rev)
+			}
+		}
+
+		for i := 0; i < n; i++ {
+			c, err := LoadCommit(hash)
+			if err != nil {
+				return "", err
+			}
+			if c.Parent == "" {
+				return "", fmt.Errorf("invalid revision %s: history is too short", rev)
+			}
+			hash = c.Parent
+		}
+	}
+	return hash, nil
+}
+
+// ParseDate parses the date expressions accepted by <rev>@{...}: now, today,
+// yesterday, "N <unit>s ago" (or N.units.ago) and absolute local dates/times.
+func ParseDate(expr string, now time.Time) (time.Time, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+
+	switch expr {
+	case "now":
+		return now, nil
+	case "today":
+		y, mo, d := now.Date()
+		return time.Date(y, mo, d, 0, 0, 0, 0, now.Location()), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	if m := relativeDatePattern.FindStringSubmatch(expr); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "second":
+			return now.Add(-time.Duration(n) * time.Second), nil
+		case "minute":
+			return now.Add(-time.Duration(n) * time.Minute), nil
+		case "hour":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "day":
+			return now.AddDate(0, 0, -n), nil
+		case "week":
+			return now.AddDate(0, 0, -7*n), nil
+		case "month":
+			return now.AddDate(0, -n, 0), nil
+		case "year":
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(expr)); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, expr, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date: %s", expr)
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"trace/internal/core"
+)
+
+func TestResolveCommitRevisions(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "trace_revision_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(tmpDir)
+
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three commits, one day apart
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	var hashes []string
+	parent := ""
+	for i, msg := range []string{"first", "second", "third"} {
+		c := &core.Commit{
+			Parent:    parent,
+			Timestamp: base.AddDate(0, 0, i).Format(time.RFC3339),
+			Message:   msg,
+		}
+		c.Hash = core.HashString(msg)
+		SaveCommit(c)
+		core.SetHEAD(c.Hash, "snap", msg)
+		hashes = append(hashes, c.Hash)
+		parent = c.Hash
+	}
+
+	tests := []struct {
+		rev  string
+		want string
+	}{
+		{"HEAD", hashes[2]},
+		{"@", hashes[2]},
+		{"main", hashes[2]},
+		{"HEAD~1", hashes[1]},
+		{"HEAD^", hashes[1]},
+		{"HEAD^^", hashes[0]},
+		{"main~2", hashes[0]},
+		{"HEAD~1^0", hashes[1]},
+		{hashes[1][:8] + "~1", hashes[0]},
+		{"HEAD@{1}", hashes[1]},
+		{"main@{2}", hashes[0]},
+		{"main@{2026-10-02 18:00}", hashes[1]},
+		{"@{2026-10-04}", hashes[2]},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolveCommit(tt.rev)
+		if err != nil {
+			t.Errorf("ResolveCommit(%s) error: %v", tt.rev, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveCommit(%s) = %s, want %s", tt.rev, core.ShortHash(got), core.ShortHash(tt.want))
+		}
+	}
+
+	auto := &core.Commit{Parent: hashes[2], Timestamp: base.AddDate(0, 0, 3).Format(time.RFC3339), Message: "auto"}
+	auto.Hash = core.HashString("auto")
+	SaveCommit(auto)
+	core.SetAuto("main", auto.Hash, "auto")
+	for _, rev := range []string{"auto/main", "auto/main@{0}"} {
+		if got, err := ResolveCommit(rev); err != nil || got != auto.Hash {
+			t.Errorf("ResolveCommit(%s) = %s, %v, want %s", rev, core.ShortHash(got), err, core.ShortHash(auto.Hash))
+		}
+	}
+	if head, _ := ResolveCommit("HEAD"); head != hashes[2] {
+		t.Error("Auto-snapshot moved HEAD")
+	}
+
+	for _, bad := range []string{"HEAD~3", "HEAD^2", "main@{2026-09-01}", "nope", "auto/staging"} {
+		if _, err := ResolveCommit(bad); err == nil {
+			t.Errorf("ResolveCommit(%s) expected error", bad)
+		}
+	}
+
+	from, to, err := ResolveRange("HEAD~2..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commits, err := GetCommitRange(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 || commits[0].Hash != hashes[2] || commits[1].Hash != hashes[1] {
+		t.Errorf("GetCommitRange(HEAD~2..) returned %d commits", len(commits))
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"yesterday", now.AddDate(0, 0, -1)},
+		{"today", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"2 days ago", now.AddDate(0, 0, -2)},
+		{"3.hours.ago", now.Add(-3 * time.Hour)},
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDate(tt.expr, now)
+		if err != nil {
+			t.Errorf("ParseDate(%s) error: %v", tt.expr, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDate(%s) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
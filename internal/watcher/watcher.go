@@ -0,0 +1,232 @@
+// Package watcher reports changes to the tracked files of a project and to
+// the refs and settings under .trace, using filesystem notifications
+// (inotify, kqueue, ...) instead of polling.
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long the files must stay quiet before a change is
+// reported, so that an editor saving in several steps yields one Change.
+const DefaultDebounce = 200 * time.Millisecond
+
+// Change lists the paths that changed, relative to the project root with
+// forward slashes (e.g. ".env", ".trace/refs/heads/main"), sorted.
+type Change struct {
+	Paths []string
+}
+
+// Refs reports whether HEAD or a branch moved.
+func (c Change) Refs() bool {
+	for _, p := range c.Paths {
+		if p == ".trace/HEAD" || strings.HasPrefix(p, ".trace/refs/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Files reports whether a tracked file or .traceignore changed, as opposed
+// to something under .trace.
+func (c Change) Files() bool {
+	for _, p := range c.Paths {
+		if !strings.HasPrefix(p, ".trace/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Config reports whether .trace/config.json changed, which may change the
+// set of tracked files.
+func (c Change) Config() bool {
+	for _, p := range c.Paths {
+		if p == ".trace/config.json" {
+			return true
+		}
+	}
+	return false
+}
+
+// Watcher delivers debounced changes of a project on Changes.
+type Watcher struct {
+	Changes <-chan Change
+	Errors  <-chan error
+
+	root     string
+	debounce time.Duration
+	fs       *fsnotify.Watcher
+	changes  chan Change
+	errors   chan error
+	done     chan struct{}
+
+	mu    sync.Mutex
+	files map[string]bool // Tracked files, relative to root
+}
+
+// New watches the tracked files (relative to root) and .trace under root.
+// Directories rather than files are watched, so files that editors replace
+// on save, or that do not exist yet, are still seen.
+func New(root string, files []string, debounce time.Duration) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(chan Change)
+	errors := make(chan error, 1)
+	w := &Watcher{
+		Changes:  changes,
+		Errors:   errors,
+		root:     root,
+		debounce: debounce,
+		fs:       fs,
+		changes:  changes,
+		errors:   errors,
+		done:     make(chan struct{}),
+	}
+
+	for _, dir := range []string{root, filepath.Join(root, ".trace")} {
+		if err := fs.Add(dir); err != nil {
+			fs.Close()
+			return nil, err
+		}
+	}
+	w.addTree(filepath.Join(root, ".trace", "refs"))
+	if err := w.SetFiles(files); err != nil {
+		fs.Close()
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// SetFiles replaces the set of tracked files, e.g. after the config changed.
+func (w *Watcher) SetFiles(files []string) error {
+	set := make(map[string]bool, len(files))
+	for _, f := range files {
+		rel := filepath.ToSlash(filepath.Clean(f))
+		set[rel] = true
+
+		dir := filepath.Join(w.root, filepath.Dir(filepath.FromSlash(rel)))
+		if _, err := os.Stat(dir); err != nil {
+			continue // Created later, or never
+		}
+		if err := w.fs.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	w.files = set
+	w.mu.Unlock()
+	return nil
+}
+
+// Close stops watching and closes Changes.
+func (w *Watcher) Close() error {
+	select {
+	case <-w.done:
+		return nil
+	default:
+	}
+	close(w.done)
+	return w.fs.Close()
+}
+
+func (w *Watcher) run() {
+	defer close(w.changes)
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			rel, ok := w.relevant(event)
+			if !ok {
+				continue
+			}
+			pending[rel] = true
+			timer.Reset(w.debounce)
+
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case w.errors <- err:
+			default: // Drop errors nobody reads
+			}
+
+		case <-timer.C:
+			change := Change{Paths: make([]string, 0, len(pending))}
+			for p := range pending {
+				change.Paths = append(change.Paths, p)
+			}
+			sort.Strings(change.Paths)
+			pending = make(map[string]bool)
+
+			select {
+			case w.changes <- change:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// relevant returns the path of an event relative to root if it concerns a
+// tracked file, .traceignore, HEAD, a ref, the config or the policy.
+func (w *Watcher) relevant(event fsnotify.Event) (string, bool) {
+	rel, err := filepath.Rel(w.root, event.Name)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+
+	if name, ok := strings.CutPrefix(rel, ".trace/"); ok {
+		if strings.HasPrefix(name, "refs/") {
+			// Branches with slashes live in subdirectories
+			if event.Has(fsnotify.Create) {
+				w.addTree(event.Name)
+			}
+			return rel, true
+		}
+		return rel, name == "HEAD" || name == "config.json" || strings.HasPrefix(name, "policy")
+	}
+	if rel == ".traceignore" {
+		return rel, true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return rel, w.files[rel]
+}
+
+// addTree watches dir and its subdirectories, if dir is a directory.
+func (w *Watcher) addTree(dir string) {
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		w.fs.Add(path)
+		return nil
+	})
+}
@@ -0,0 +1,102 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T) (string, *Watcher) {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{".trace/refs/heads", "config"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(t, root, ".env", "A=1\n")
+
+	w, err := New(root, []string{".env", "config/app.yaml"}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return root, w
+}
+
+func write(t *testing.T, root, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func next(t *testing.T, w *Watcher) Change {
+	t.Helper()
+	select {
+	case c := <-w.Changes:
+		return c
+	case <-time.After(2 * time.Second):
+		t.Fatal("No change reported")
+		return Change{}
+	}
+}
+
+func TestTrackedFiles(t *testing.T) {
+	root, w := setup(t)
+
+	write(t, root, "untracked.txt", "x")
+	write(t, root, ".env", "A=2\n")
+	write(t, root, ".env", "A=3\n")
+	write(t, root, "config/app.yaml", "a: 1\n") // Did not exist before
+
+	c := next(t, w)
+	if want := []string{".env", "config/app.yaml"}; !reflect.DeepEqual(c.Paths, want) {
+		t.Errorf("Paths = %v, want %v", c.Paths, want)
+	}
+	if !c.Files() || c.Refs() || c.Config() {
+		t.Errorf("Change %v should not touch refs or config", c.Paths)
+	}
+
+	select {
+	case c := <-w.Changes:
+		t.Errorf("Unexpected second change %v", c.Paths)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestRefs(t *testing.T) {
+	root, w := setup(t)
+
+	if err := os.MkdirAll(filepath.Join(root, ".trace/refs/heads/feature"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	next(t, w)
+	write(t, root, ".trace/refs/heads/feature/x", "abc\n")
+	write(t, root, ".trace/objects", "ignored")
+
+	c := next(t, w)
+	if want := []string{".trace/refs/heads/feature/x"}; !reflect.DeepEqual(c.Paths, want) {
+		t.Errorf("Paths = %v, want %v", c.Paths, want)
+	}
+	if !c.Refs() || c.Files() {
+		t.Errorf("Refs() = %v, Files() = %v", c.Refs(), c.Files())
+	}
+
+	write(t, root, ".trace/config.json", "{}")
+	if c := next(t, w); !c.Config() {
+		t.Errorf("Config() = false for %v", c.Paths)
+	}
+}
+
+func TestClose(t *testing.T) {
+	_, w := setup(t)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-w.Changes; ok {
+		t.Error("Changes still open after Close")
+	}
+}